@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pstrobl96/prusa_exporter/syslog"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	captureCommand       = kingpin.Command("capture", "Record syslog metric packets sent by printers into a file.")
+	captureListenAddress = captureCommand.Flag("capture.listen-address", "Address where to listen for syslog metric packets.").Default("0.0.0.0:10008").String()
+	captureFile          = captureCommand.Flag("capture.file", "File where to store captured packets.").Default("./capture.jsonl").String()
+	captureCount         = captureCommand.Flag("capture.count", "Stop after this number of packets, 0 means no limit.").Default("0").Int()
+
+	replayCommand = kingpin.Command("replay", "Send syslog metric packets from a capture file to a listener.")
+	replayFile    = replayCommand.Flag("replay.file", "Capture file created by the capture command.").Default("./capture.jsonl").ExistingFile()
+	replayTarget  = replayCommand.Flag("replay.target", "Address of the syslog metrics listener.").Default("127.0.0.1:10008").String()
+	replaySpeed   = replayCommand.Flag("replay.speed", "Replay speed multiplier, 1 keeps original timing and 0 sends packets without delay.").Default("1").Float64()
+)
+
+// runCapture function to record syslog packets until the count is reached or the process is stopped
+func runCapture() {
+	if err := syslog.Capture(*captureListenAddress, *captureFile, *captureCount); err != nil {
+		log.Error().Msg("Error capturing syslog packets " + err.Error())
+		os.Exit(1)
+	}
+	log.Info().Msg("Capture finished")
+}
+
+// runReplay function to resend captured syslog packets
+func runReplay() {
+	if err := syslog.Replay(*replayFile, *replayTarget, *replaySpeed); err != nil {
+		log.Error().Msg("Error replaying syslog packets " + err.Error())
+		os.Exit(1)
+	}
+	log.Info().Msg("Replay finished")
+}
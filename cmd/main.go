@@ -1,14 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/pstrobl96/prusa_exporter/discovery"
 	"github.com/pstrobl96/prusa_exporter/prusalink"
 	"github.com/pstrobl96/prusa_exporter/syslog"
 	"github.com/rs/zerolog"
@@ -16,15 +21,38 @@ import (
 )
 
 var (
-	configFile  = kingpin.Flag("config.file", "Configuration file for prusa_exporter.").Default("./prusa.yml").ExistingFile()
-	metricsPath = kingpin.Flag("exporter.metrics-path", "Path where to expose metrics.").Default("/metrics").String()
-	metricsPort = kingpin.Flag("exporter.metrics-port", "Port where to expose metrics.").Default("10009").Int()
-	syslogTTL   = kingpin.Flag("syslog.ttl", "TTL for syslog metrics in seconds.").Default("60").Int()
+	configFile      = kingpin.Flag("config.file", "Configuration file for prusa_exporter.").Default("./prusa.yml").String()
+	metricsPath     = kingpin.Flag("exporter.metrics-path", "Path where to expose metrics.").Default("/metrics").String()
+	metricsPort     = kingpin.Flag("exporter.metrics-port", "Port where to expose metrics.").Default("10009").Int()
+	galleryPath     = kingpin.Flag("exporter.gallery-path", "Path where to expose thumbnails and metadata of printed files.").Default("/gallery").String()
+	camerasPath     = kingpin.Flag("exporter.cameras-path", "Path where to expose camera snapshots.").Default("/cameras").String()
+	qualityPath     = kingpin.Flag("exporter.quality-path", "Path where to expose print quality reports derived from syslog metrics.").Default("/quality").String()
+	maintenancePath = kingpin.Flag("exporter.maintenance-path", "Path where to expose odometers and maintenance tasks of printers.").Default("/maintenance").String()
+	jobsPath        = kingpin.Flag("exporter.jobs-path", "Path where to expose costs of ended jobs.").Default("/api/jobs").String()
+	meshPath        = kingpin.Flag("exporter.mesh-path", "Path where to expose bed meshes recorded from syslog metrics.").Default("/mesh").String()
+	discoveryPath   = kingpin.Flag("exporter.discovery-path", "Path where to expose printers discovered on the network.").Default("/discovery").String()
+	syslogTTL       = kingpin.Flag("syslog.ttl", "TTL for syslog metrics in seconds.").Default("60").Int()
+	meshHistory     = kingpin.Flag("syslog.mesh-history", "Number of bed meshes stored per printer.").Default("10").Int()
+
+	exporterCommand = kingpin.Command("run", "Run the exporter.").Default()
 )
 
-// Run function to start the exporter
+// Run function to parse the command line and start the selected command - the exporter by default
 func Run() {
-	kingpin.Parse()
+	switch kingpin.Parse() {
+	case captureCommand.FullCommand():
+		runCapture()
+	case replayCommand.FullCommand():
+		runReplay()
+	case simulateCommand.FullCommand():
+		runSimulate()
+	case exporterCommand.FullCommand():
+		runExporter()
+	}
+}
+
+// runExporter function to start the exporter
+func runExporter() {
 	log.Info().Msg("Prusa exporter starting")
 	log.Info().Msg("Loading configuration file: " + *configFile)
 
@@ -43,6 +71,7 @@ func Run() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnixNano
 
 	var collectors []prometheus.Collector
+	saves := map[string]func() error{} // state saved when the exporter is stopped
 
 	if config.Exporter.Prusalink.Enabled {
 		config, err = probeConfigFile(config)
@@ -52,14 +81,50 @@ func Run() {
 			os.Exit(1)
 		}
 		log.Info().Msg("PrusaLink metrics enabled!")
-		collectors = append(collectors, prusalink.NewCollector(config))
+	}
+
+	if config.Exporter.Connect.Enabled {
+		log.Info().Msg("Prusa Connect metrics enabled!")
+	}
+
+	if config.Exporter.Prusalink.Enabled || config.Exporter.Connect.Enabled {
+		prusalinkConfig := config
+		if !config.Exporter.Prusalink.Enabled {
+			prusalinkConfig.Printers = nil // printers from Prusa Connect only
+		}
+		collectors = append(collectors, prusalink.NewCollector(prusalinkConfig))
+
+		if config.Exporter.Fleet.Enabled {
+			collectors = append(collectors, prusalink.NewFleetCollector())
+			log.Info().Msg("Fleet metrics enabled!")
+		}
+
+		if config.Exporter.Maintenance.Enabled {
+			if err := prusalink.LoadOdometers(); err != nil {
+				log.Error().Msg("Error loading odometers " + err.Error())
+				os.Exit(1)
+			}
+			go prusalink.RunOdometerSaves()
+			saves["odometers"] = prusalink.SaveOdometers
+			log.Info().Msg("Maintenance odometers enabled!")
+		}
 	}
 
 	if config.Exporter.Syslog.Metrics.Enabled {
 		log.Info().Msg("Syslog metrics enabled!")
 		log.Info().Msg("Syslog metrics server starting at: " + config.Exporter.Syslog.Metrics.ListenAddress)
-		go syslog.HandleMetrics(config.Exporter.Syslog.Metrics.ListenAddress)
-		collectors = append(collectors, syslog.NewCollector(*syslogTTL))
+		if err := syslog.LoadSteppers(config.Exporter.Syslog.Metrics.StepperFile); err != nil {
+			log.Error().Msg("Error loading stepper normals " + err.Error())
+			os.Exit(1)
+		}
+		go syslog.RunStepperSaves()
+		saves["stepper normals"] = syslog.SaveSteppers
+		go syslog.HandleMetrics(config.Exporter.Syslog.Metrics.ListenAddress, *meshHistory)
+		collectors = append(collectors, syslog.NewCollector(*syslogTTL, config.Exporter.MetricNames, config))
+	}
+
+	if len(saves) > 0 {
+		go saveOnExit(saves)
 	}
 
 	if config.Exporter.Syslog.Logs.Enabled {
@@ -81,26 +146,71 @@ func Run() {
 	prometheus.MustRegister(collectors...)
 	log.Info().Msg("Metrics registered")
 	http.Handle(*metricsPath, promhttp.Handler())
+	if config.Exporter.Prusalink.Enabled {
+		http.Handle(strings.TrimSuffix(*galleryPath, "/")+"/", prusalink.GalleryHandler(*galleryPath))
+		log.Info().Msg("Gallery of printed files at: " + *galleryPath)
+	}
+	if config.Exporter.Prusalink.Enabled && config.Exporter.Prusalink.Cameras.Enabled {
+		go prusalink.RunCameraSnapshots()
+		http.Handle(strings.TrimSuffix(*camerasPath, "/")+"/", prusalink.CameraHandler(*camerasPath))
+		log.Info().Msg("Camera snapshots at: " + *camerasPath)
+	}
+	if config.Exporter.Discovery.Enabled {
+		if config.Exporter.Prusalink.Enabled {
+			go discovery.Run(config)
+			http.Handle(strings.TrimSuffix(*discoveryPath, "/")+"/", discovery.Handler(*discoveryPath))
+			log.Info().Msg("Discovered printers at: " + *discoveryPath)
+		} else {
+			log.Error().Msg("Discovery of printers needs PrusaLink metrics enabled")
+		}
+	}
+	if config.Exporter.Maintenance.Enabled && (config.Exporter.Prusalink.Enabled || config.Exporter.Connect.Enabled) {
+		http.Handle(strings.TrimSuffix(*maintenancePath, "/")+"/", prusalink.MaintenanceHandler(*maintenancePath))
+		log.Info().Msg("Maintenance of printers at: " + *maintenancePath)
+	}
+	if config.Exporter.Costs.Enabled && (config.Exporter.Prusalink.Enabled || config.Exporter.Connect.Enabled) {
+		http.Handle(strings.TrimSuffix(*jobsPath, "/")+"/", prusalink.JobsHandler(*jobsPath))
+		log.Info().Msg("Costs of jobs at: " + *jobsPath)
+	}
+	if config.Exporter.Syslog.Metrics.Enabled {
+		http.Handle(strings.TrimSuffix(*qualityPath, "/")+"/", syslog.QualityHandler(*qualityPath))
+		log.Info().Msg("Print quality reports at: " + *qualityPath)
+		http.Handle(strings.TrimSuffix(*meshPath, "/")+"/", syslog.MeshHandler(*meshPath))
+		log.Info().Msg("Bed meshes at: " + *meshPath)
+	}
 	log.Info().Msg("Listening at port: " + strconv.Itoa(*metricsPort))
 	log.Fatal().Msg(http.ListenAndServe(":"+strconv.Itoa(*metricsPort), nil).Error())
 
 }
 
+// saveOnExit saves changed odometers and stepper normals when the exporter is stopped by SIGINT or SIGTERM and exits
+func saveOnExit(saves map[string]func() error) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	code := 0
+	for name, save := range saves {
+		if err := save(); err != nil {
+			log.Error().Msg("Error while saving " + name + " - " + err.Error())
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
 func probeConfigFile(config config.Config) (config.Config, error) {
-	for i, printer := range config.Printers {
+	for _, printer := range config.Printers {
 		if printer.Type == "" {
 			status, err := prusalink.ProbePrinter(printer)
 			if err != nil {
 				log.Error().Msg(err.Error())
 			} else if status {
 
-				printerType, err := prusalink.GetPrinterType(printer)
-
-				if err != nil {
+				// type is not stored in configuration so detection can be refined later, e.g. by syslog hints or printed file
+				if _, err := prusalink.GetPrinterType(context.Background(), printer); err != nil {
 					log.Error().Msg(err.Error())
 				}
-
-				config.Printers[i].Type = printerType
 			}
 		}
 	}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pstrobl96/prusa_exporter/simulator"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	simulateCommand        = kingpin.Command("simulate", "Run a simulated PrusaLink printer for tests and demos.")
+	simulateModel          = simulateCommand.Flag("simulate.model", "Simulated printer model - "+strings.Join(simulator.Models(), ", ")+".").Default("MK4").String()
+	simulateListenAddress  = simulateCommand.Flag("simulate.listen-address", "Address where the simulated PrusaLink API listens.").Default("127.0.0.1:10080").String()
+	simulateUsername       = simulateCommand.Flag("simulate.username", "Username for digest authentication.").Default("maker").String()
+	simulatePassword       = simulateCommand.Flag("simulate.password", "Password for digest authentication, empty disables digest.").Default("").String()
+	simulateAPIKey         = simulateCommand.Flag("simulate.apikey", "API key for X-Api-Key authentication, empty disables API key.").Default("").String()
+	simulateScript         = simulateCommand.Flag("simulate.script", "Job lifecycle repeated forever in format STATE:DURATION,... - e.g. IDLE:30s,PRINTING:10m,FINISHED:1m.").Default("").String()
+	simulateFaults         = simulateCommand.Flag("simulate.fault", "Injected fault in format PATH=STATUS[@PROBABILITY] - e.g. /api/v1/status=500@0.5. Can be repeated.").Strings()
+	simulateLatency        = simulateCommand.Flag("simulate.latency", "Latency added to every response.").Default("0s").Duration()
+	simulateSyslogTarget   = simulateCommand.Flag("simulate.syslog-target", "Address of syslog metrics listener, empty disables syslog metrics.").Default("").String()
+	simulateSyslogInterval = simulateCommand.Flag("simulate.syslog-interval", "Interval of sending syslog metrics.").Default("1s").Duration()
+	simulateMAC            = simulateCommand.Flag("simulate.mac", "MAC address sent in syslog metrics.").Default("10:9c:70:2c:da:08").String()
+	simulateMMU            = simulateCommand.Flag("simulate.mmu", "Simulate printer with MMU3.").Default("false").Bool()
+	simulateCameras        = simulateCommand.Flag("simulate.cameras", "Number of cameras connected to simulated PrusaLink, only MK3 family has cameras.").Default("0").Int()
+)
+
+// runSimulate function to start the simulated printer
+func runSimulate() {
+	script, err := simulator.ParseScript(*simulateScript)
+	if err != nil {
+		log.Error().Msg("Error parsing simulator script " + err.Error())
+		os.Exit(1)
+	}
+
+	var faults []simulator.Fault
+	for _, f := range *simulateFaults {
+		fault, err := simulator.ParseFault(f)
+		if err != nil {
+			log.Error().Msg("Error parsing simulator fault " + err.Error())
+			os.Exit(1)
+		}
+		faults = append(faults, fault)
+	}
+
+	printer, err := simulator.New(simulator.Options{
+		Model:    *simulateModel,
+		Username: *simulateUsername,
+		Password: *simulatePassword,
+		APIKey:   *simulateAPIKey,
+		Script:   script,
+		Faults:   faults,
+		Latency:  *simulateLatency,
+		MAC:      *simulateMAC,
+		MMU:      *simulateMMU,
+		Cameras:  *simulateCameras,
+	})
+	if err != nil {
+		log.Error().Msg("Error creating simulator " + err.Error())
+		os.Exit(1)
+	}
+
+	if *simulateSyslogTarget != "" {
+		log.Info().Msg("Simulator sending syslog metrics to: " + *simulateSyslogTarget)
+		go func() {
+			if err := printer.EmitSyslog(*simulateSyslogTarget, *simulateSyslogInterval, nil); err != nil {
+				log.Error().Msg("Error sending simulated syslog metrics " + err.Error())
+			}
+		}()
+	}
+
+	log.Info().Msg("Simulated " + printer.Model().Type + " listening at: " + *simulateListenAddress)
+	log.Fatal().Msg(http.ListenAndServe(*simulateListenAddress, printer).Error())
+}
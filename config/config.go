@@ -1,7 +1,12 @@
 package config
 
 import (
+	"errors"
 	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/rs/zerolog"
 	"gopkg.in/yaml.v3"
@@ -12,13 +17,57 @@ type Config struct {
 	Exporter struct {
 		ScrapeTimeout int    `yaml:"scrape_timeout"`
 		LogLevel      string `yaml:"log_level"`
+		MetricNames   string `yaml:"metric_names"`
 		Prusalink     struct {
-			Enabled bool `yaml:"enabled"`
+			Enabled   bool   `yaml:"enabled"`
+			JobLabels string `yaml:"job_labels"`
+			Files     struct {
+				Inventory bool `yaml:"inventory"`
+				Limit     int  `yaml:"limit"`
+			} `yaml:"files"`
+			Cameras struct {
+				Enabled  bool   `yaml:"enabled"`
+				Interval int    `yaml:"interval"`
+				Username string `yaml:"username,omitempty"`
+				Password string `yaml:"password,omitempty"`
+			} `yaml:"cameras"`
 		} `yaml:"prusalink"`
+		Connect struct {
+			Enabled bool   `yaml:"enabled"`
+			URL     string `yaml:"url"`
+			Token   string `yaml:"token"`
+			TeamID  int    `yaml:"team_id"`
+		} `yaml:"connect"`
+		Maintenance struct {
+			Enabled bool              `yaml:"enabled"`
+			File    string            `yaml:"file"`
+			Tasks   []MaintenanceTask `yaml:"tasks"`
+		} `yaml:"maintenance"`
+		Costs struct {
+			Enabled     bool               `yaml:"enabled"`
+			Currency    string             `yaml:"currency"`
+			Electricity float64            `yaml:"electricity"`  // price of kWh
+			MachineRate float64            `yaml:"machine_rate"` // price of hour of printing
+			Materials   map[string]float64 `yaml:"materials"`    // material -> price of kg
+			History     int                `yaml:"history"`      // number of ended jobs kept in memory
+		} `yaml:"costs"`
+		Fleet struct {
+			Enabled bool `yaml:"enabled"`
+		} `yaml:"fleet"`
+		Discovery struct {
+			Enabled  bool     `yaml:"enabled"`
+			MDNS     bool     `yaml:"mdns"`
+			Services []string `yaml:"services"` // DNS-SD services browsed by mDNS
+			Subnets  []string `yaml:"subnets"`  // CIDR ranges scanned for printers
+			Interval int      `yaml:"interval"` // seconds between discoveries
+			AutoAdd  bool     `yaml:"auto_add"` // discovered printers are scraped without approval
+			Profile  Printers `yaml:"profile"`  // credentials, group and labels of discovered printers
+		} `yaml:"discovery"`
 		Syslog struct {
 			Metrics struct {
 				Enabled       bool   `yaml:"enabled"`
 				ListenAddress string `yaml:"listen_address"`
+				StepperFile   string `yaml:"stepper_file"` // learned StallGuard normals of steppers
 			} `yaml:"metrics"`
 			Logs struct {
 				Enabled       bool   `yaml:"enabled"`
@@ -31,20 +80,43 @@ type Config struct {
 			} `yaml:"logs"`
 		} `yaml:"syslog"`
 	} `yaml:"exporter"`
-	Printers []Printers `yaml:"printers"`
+	Groups   map[string]Group `yaml:"groups"`
+	Printers []Printers       `yaml:"printers"`
+}
+
+// Group is group or site of printers, its labels are added to metrics of all printers of the group
+type Group struct {
+	Labels map[string]string `yaml:"labels"`
+}
+
+// MaintenanceTask is maintenance that is due every given amount of the counter, e.g. every 200 hours of printing
+type MaintenanceTask struct {
+	Name      string  `yaml:"name"`
+	Counter   string  `yaml:"counter"`
+	Component string  `yaml:"component,omitempty"` // axis, heater or fan of the counter, sum of all of them when empty
+	Every     float64 `yaml:"every"`
 }
 
 // Printers struct containing the printer configuration
 type Printers struct {
-	Address   string `yaml:"address"`
-	Username  string `yaml:"username,omitempty"`
-	Password  string `yaml:"password,omitempty"`
-	Apikey    string `yaml:"apikey,omitempty"`
-	Name      string `yaml:"name,omitempty"`
-	Type      string `yaml:"type,omitempty"`
+	Address   string            `yaml:"address"`
+	Username  string            `yaml:"username,omitempty"`
+	Password  string            `yaml:"password,omitempty"`
+	Apikey    string            `yaml:"apikey,omitempty"`
+	Name      string            `yaml:"name,omitempty"`
+	Type      string            `yaml:"type,omitempty"`
+	Group     string            `yaml:"group,omitempty"`  // group or site of the printer used by fleet metrics and group label
+	Labels    map[string]string `yaml:"labels,omitempty"` // custom labels, e.g. room or owner, they override labels of the group
+	Plug      Plug              `yaml:"plug,omitempty"`
 	Reachable bool
 }
 
+// Plug is smart plug that measures power of the printer, type is shelly or tasmota
+type Plug struct {
+	Type    string `yaml:"type"`
+	Address string `yaml:"address"`
+}
+
 // LoadConfig function to load and parse the configuration file
 func LoadConfig(path string) (Config, error) {
 	var config Config
@@ -58,7 +130,106 @@ func LoadConfig(path string) (Config, error) {
 		return config, err
 	}
 
-	return config, err
+	return config, config.ValidateLabels()
+}
+
+var (
+	// builtinLabels are labels of metrics of the exporter, custom labels can not use them
+	builtinLabels = map[string]bool{
+		// prusalink and fleet metrics
+		"printer_address": true, "printer_model": true, "printer_name": true, "printer_job_name": true, "printer_job_path": true,
+		"printer_axis": true, "printer_filament": true, "printer_state": true, "printer_storage": true, "printer_location": true,
+		"printer_hostname": true, "api_version": true, "server_version": true, "version_text": true, "prusalink_name": true,
+		"serial_number": true, "camera_id": true, "camera_name": true, "camera_resolution": true, "exposure": true, "fan": true,
+		"heater": true, "material": true, "source": true, "task": true, "log_name": true, "file_path": true, "file_name": true,
+		"storage_type": true, "files": true, "slot": true, "tilt": true, "tool": true, "group": true, "state": true, "window": true,
+		// syslog metrics
+		"mac": true, "ip": true, "axis": true, "attempts": true, "reg_addr": true, "reg_addr_name": true, "sens": true,
+		"period": true, "bedlet": true, "desc": true, "device": true, "filament": true, "file": true, "filename": true,
+		"gcode": true, "interface": true, "msg": true, "rail": true, "revision": true, "bom": true, "sensor": true,
+		"result": true, "version": true, "t": true, "p": true, "a": true, "x": true, "y": true, "z": true,
+		// labels added by prometheus to histograms and summaries
+		"le": true, "quantile": true,
+	}
+
+	labelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// IsBuiltinLabel returns true when the label is used by metrics of the exporter
+func IsBuiltinLabel(name string) bool {
+	return builtinLabels[name]
+}
+
+// GetPrinterLabels returns custom labels of the printer - labels of its group overridden by its own labels,
+// name of the group is the group label
+func (config Config) GetPrinterLabels(printer Printers) map[string]string {
+	labels := map[string]string{}
+	if printer.Group != "" {
+		for name, value := range config.Groups[printer.Group].Labels {
+			labels[name] = value
+		}
+	}
+	for name, value := range printer.Labels {
+		labels[name] = value
+	}
+	if printer.Group != "" {
+		labels["group"] = printer.Group
+	}
+	return labels
+}
+
+// GetLabelNames returns sorted names of custom labels of all printers and of the discovery profile, every printer has all
+// of them so series of one metric have the same labels
+func (config Config) GetLabelNames() []string {
+	unique := map[string]bool{}
+	printers := config.Printers
+	if config.Exporter.Discovery.Enabled {
+		printers = append(slices.Clip(printers), config.Exporter.Discovery.Profile)
+	}
+	for _, printer := range printers {
+		for name := range config.GetPrinterLabels(printer) {
+			unique[name] = true
+		}
+	}
+
+	names := []string{}
+	for name := range unique {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateLabels returns error when custom label of a group or a printer is not a valid label name or it is used
+// by metrics of the exporter, or when groups are defined and the printer is in a group that is not
+func (config Config) ValidateLabels() error {
+	validate := func(owner string, group string, labels map[string]string) error {
+		if _, ok := config.Groups[group]; group != "" && len(config.Groups) > 0 && !ok {
+			return errors.New("group " + group + " of " + owner + " is not defined in groups")
+		}
+		for name := range labels {
+			if !labelName.MatchString(name) || strings.HasPrefix(name, "__") {
+				return errors.New("label " + name + " of " + owner + " is not a valid label name")
+			}
+			if builtinLabels[name] {
+				return errors.New("label " + name + " of " + owner + " is used by metrics of the exporter")
+			}
+		}
+		return nil
+	}
+
+	for name, group := range config.Groups {
+		if err := validate("group "+name, "", group.Labels); err != nil {
+			return err
+		}
+	}
+	for _, printer := range config.Printers {
+		if err := validate("printer "+printer.Address, printer.Group, printer.Labels); err != nil {
+			return err
+		}
+	}
+	profile := config.Exporter.Discovery.Profile
+	return validate("discovery profile", profile.Group, profile.Labels)
 }
 
 // GetLogLevel function to parse the log level for zerolog
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestValidateLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		group   map[string]string
+		printer map[string]string
+		in      string // group of the printer
+		valid   bool
+	}{
+		{"no labels", nil, nil, "farm", true},
+		{"custom labels", map[string]string{"site": "prague"}, map[string]string{"room": "lab", "owner_2": "jan"}, "farm", true},
+		{"builtin printer label", nil, map[string]string{"printer_name": "mk4"}, "farm", false},
+		{"builtin syslog label", map[string]string{"mac": "00:00"}, nil, "farm", false},
+		{"histogram label", nil, map[string]string{"le": "1"}, "farm", false},
+		{"summary label", map[string]string{"quantile": "0.5"}, nil, "farm", false},
+		{"group label", nil, map[string]string{"group": "farm"}, "farm", false},
+		{"invalid name", nil, map[string]string{"material-profile": "pla"}, "farm", false},
+		{"reserved name", map[string]string{"__site": "prague"}, nil, "farm", false},
+		{"undefined group", nil, nil, "frm", false},
+		{"no group", nil, nil, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var config Config
+			config.Groups = map[string]Group{"farm": {Labels: test.group}}
+			config.Printers = []Printers{{Address: "192.168.20.12", Group: test.in, Labels: test.printer}}
+
+			if err := config.ValidateLabels(); (err == nil) != test.valid {
+				t.Errorf("ValidateLabels() returned %v, want valid %v", err, test.valid)
+			}
+		})
+	}
+}
+
+func TestPrinterLabels(t *testing.T) {
+	var config Config
+	config.Groups = map[string]Group{"farm": {Labels: map[string]string{"site": "prague", "room": "hall"}}}
+	config.Printers = []Printers{
+		{Address: "192.168.20.11", Group: "farm", Labels: map[string]string{"room": "lab"}},
+		{Address: "192.168.20.12", Labels: map[string]string{"owner": "jan"}},
+		{Address: "192.168.20.13"},
+	}
+
+	want := map[string]string{"group": "farm", "site": "prague", "room": "lab"}
+	if labels := config.GetPrinterLabels(config.Printers[0]); !maps.Equal(labels, want) {
+		t.Errorf("labels of the printer are %v, want %v", labels, want)
+	}
+	if labels := config.GetPrinterLabels(config.Printers[2]); len(labels) != 0 {
+		t.Errorf("printer without labels has labels %v", labels)
+	}
+	if names := config.GetLabelNames(); !slices.Equal(names, []string{"group", "owner", "room", "site"}) {
+		t.Errorf("label names are %v", names)
+	}
+
+	// discovered printers get labels of the discovery profile
+	config.Exporter.Discovery.Enabled = true
+	config.Exporter.Discovery.Profile.Labels = map[string]string{"shelf": "top"}
+	if names := config.GetLabelNames(); !slices.Equal(names, []string{"group", "owner", "room", "shelf", "site"}) {
+		t.Errorf("label names with discovery are %v", names)
+	}
+	config.Exporter.Discovery.Profile.Labels = map[string]string{"ip": "top"}
+	if err := config.ValidateLabels(); err == nil {
+		t.Error("builtin label of discovery profile is valid")
+	}
+	config.Exporter.Discovery.Profile = Printers{Group: "lab"}
+	if err := config.ValidateLabels(); err == nil {
+		t.Error("undefined group of discovery profile is valid")
+	}
+
+	// without groups, group is only a label of the printer
+	config.Groups = nil
+	if err := config.ValidateLabels(); err != nil {
+		t.Errorf("group without groups is not valid - %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package connect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// DefaultURL is the address of Prusa Connect used when url is not configured
+	DefaultURL = "https://connect.prusa3d.com"
+
+	// pageLimit is number of printers requested in one page
+	pageLimit = 100
+)
+
+// Client is a client of Prusa Connect API authenticated by API token
+type Client struct {
+	url    string
+	token  string
+	teamID int
+	client *http.Client
+}
+
+// NewClient returns a new client of Prusa Connect at the given url, DefaultURL is used when url is empty. Printers are limited to the team when teamID is not 0.
+func NewClient(url string, token string, teamID int, timeout time.Duration) *Client {
+	if url == "" {
+		url = DefaultURL
+	}
+
+	return &Client{
+		url:    strings.TrimSuffix(url, "/"),
+		token:  token,
+		teamID: teamID,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// GetPrinters returns all printers of the team, pages of /app/printers are requested until all printers are read or ctx is done
+func (c *Client) GetPrinters(ctx context.Context) ([]Printer, error) {
+	var printers []Printer
+
+	for {
+		page, err := c.getPrinters(ctx, len(printers))
+		if err != nil {
+			return printers, err
+		}
+
+		printers = append(printers, page.Printers...)
+
+		if len(page.Printers) == 0 || len(printers) >= page.Pager.Total {
+			return printers, nil
+		}
+	}
+}
+
+// getPrinters returns one page of printers starting at the offset
+func (c *Client) getPrinters(ctx context.Context, offset int) (Printers, error) {
+	var printers Printers
+
+	path := "/app/printers?limit=" + strconv.Itoa(pageLimit) + "&offset=" + strconv.Itoa(offset)
+	if c.teamID != 0 {
+		path += "&team_id=" + strconv.Itoa(c.teamID)
+	}
+
+	response, err := c.get(ctx, path)
+	if err != nil {
+		return printers, err
+	}
+
+	err = json.Unmarshal(response, &printers)
+
+	return printers, err
+}
+
+// get sends authenticated GET request to the path of Prusa Connect API
+func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.url+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Authorization", "Bearer "+c.token)
+	req.Header.Add("Accept", "application/json")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New("Prusa Connect returned " + res.Status + " for " + path)
+	}
+
+	return io.ReadAll(res.Body)
+}
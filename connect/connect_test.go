@@ -0,0 +1,68 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGetPrinters(t *testing.T) {
+	var printers []Printer
+	for i := 0; i < 5; i++ {
+		printers = append(printers, Printer{UUID: "uuid-" + strconv.Itoa(i), TeamID: 1 + i%2, PrinterState: "IDLE"})
+	}
+
+	server := httptest.NewServer(NewStandIn("token", printers...))
+	t.Cleanup(server.Close)
+
+	limit := pageLimit
+	pageLimit = 2 // more pages than printers in one page
+	t.Cleanup(func() { pageLimit = limit })
+
+	tests := []struct {
+		name   string
+		token  string
+		teamID int
+		want   []string
+		err    bool
+	}{
+		{name: "all teams", token: "token", want: []string{"uuid-0", "uuid-1", "uuid-2", "uuid-3", "uuid-4"}},
+		{name: "one team", token: "token", teamID: 2, want: []string{"uuid-1", "uuid-3"}},
+		{name: "unknown team", token: "token", teamID: 3},
+		{name: "wrong token", token: "wrong", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := NewClient(server.URL, test.token, test.teamID, time.Second).GetPrinters(context.Background())
+			if (err != nil) != test.err {
+				t.Fatalf("got error %v, want error %v", err, test.err)
+			}
+
+			if len(got) != len(test.want) {
+				t.Fatalf("got %d printers, want %d", len(got), len(test.want))
+			}
+
+			for i := range got {
+				if got[i].UUID != test.want[i] {
+					t.Errorf("printer %d is %s, want %s", i, got[i].UUID, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetPrintersCanceled(t *testing.T) {
+	server := httptest.NewServer(NewStandIn("token", Printer{UUID: "uuid-0", PrinterState: "IDLE"}))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // scrape was canceled before Prusa Connect was asked
+
+	if _, err := NewClient(server.URL, "token", 0, time.Second).GetPrinters(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,68 @@
+package connect
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// StandIn is a local stand-in of Prusa Connect API serving /app/printers for tests and demos
+type StandIn struct {
+	token    string
+	mutex    sync.Mutex
+	printers []Printer
+}
+
+// NewStandIn returns a stand-in of Prusa Connect that accepts the given API token and serves the given printers
+func NewStandIn(token string, printers ...Printer) *StandIn {
+	return &StandIn{token: token, printers: printers}
+}
+
+// SetPrinters replaces printers served by the stand-in
+func (s *StandIn) SetPrinters(printers ...Printer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.printers = printers
+}
+
+// ServeHTTP implements http.Handler, /app/printers supports team_id, limit and offset query parameters same as Prusa Connect
+func (s *StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+s.token {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	if r.URL.Path != "/app/printers" {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	teamID, _ := strconv.Atoi(query.Get("team_id"))
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = pageLimit
+	}
+
+	s.mutex.Lock()
+	var team []Printer
+	for _, printer := range s.printers {
+		if teamID == 0 || printer.TeamID == teamID {
+			team = append(team, printer)
+		}
+	}
+	s.mutex.Unlock()
+
+	page := Printers{Printers: []Printer{}}
+	page.Pager.Total, page.Pager.Limit, page.Pager.Offset = len(team), limit, offset
+
+	for i := offset; i < len(team) && i < offset+limit; i++ {
+		page.Printers = append(page.Printers, team[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
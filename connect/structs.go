@@ -0,0 +1,53 @@
+package connect
+
+// Printers is a struct that contains one page of printers from path /app/printers
+type Printers struct {
+	Printers []Printer `json:"printers"`
+	Pager    struct {
+		Total  int `json:"total"`
+		Limit  int `json:"limit"`
+		Offset int `json:"offset"`
+	} `json:"pager"`
+}
+
+// Printer is a struct that contains data about the printer registered in Prusa Connect
+type Printer struct {
+	UUID           string    `json:"uuid"`
+	Name           string    `json:"name"`
+	TeamID         int       `json:"team_id"`
+	PrinterModel   string    `json:"printer_model"` // e.g. MK4, MK3.9, XL, SL1S
+	PrinterState   string    `json:"printer_state"` // IDLE, READY, BUSY, PRINTING, PAUSED, FINISHED, STOPPED, ERROR, ATTENTION or OFFLINE
+	Location       string    `json:"location"`
+	Firmware       string    `json:"firmware"`
+	SerialNumber   string    `json:"sn"`
+	NozzleDiameter float64   `json:"nozzle_diameter"`
+	Telemetry      Telemetry `json:"telemetry"`
+	JobInfo        *JobInfo  `json:"job_info,omitempty"`        // nil when the printer does not print
+	JobQueueCount  *int      `json:"job_queue_count,omitempty"` // planned jobs in queue of the printer
+}
+
+// Telemetry is a struct that contains the last telemetry of the printer sent to Prusa Connect
+type Telemetry struct {
+	TempNozzle   float64 `json:"temp_nozzle"`
+	TempBed      float64 `json:"temp_bed"`
+	TargetNozzle float64 `json:"target_nozzle"`
+	TargetBed    float64 `json:"target_bed"`
+	AxisX        float64 `json:"axis_x"`
+	AxisY        float64 `json:"axis_y"`
+	AxisZ        float64 `json:"axis_z"`
+	Speed        float64 `json:"speed"`
+	Flow         float64 `json:"flow"`
+	FanHotend    float64 `json:"fan_hotend"`
+	FanPrint     float64 `json:"fan_print"`
+	Material     string  `json:"material"`
+}
+
+// JobInfo is a struct that contains data about the job printed by the printer
+type JobInfo struct {
+	ID            int     `json:"id"`
+	DisplayName   string  `json:"display_name"`
+	Path          string  `json:"path"`
+	Progress      float64 `json:"progress"`
+	TimePrinting  float64 `json:"time_printing"`
+	TimeRemaining float64 `json:"time_remaining"`
+}
@@ -0,0 +1,213 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/pstrobl96/prusa_exporter/prusalink"
+	"github.com/rs/zerolog/log"
+)
+
+// DiscoveredPrinter is a PrusaLink printer found on the network that is not configured
+type DiscoveredPrinter struct {
+	Address   string    `json:"printer_address"`
+	Name      string    `json:"printer_name,omitempty"` // instance name advertised by mDNS
+	Model     string    `json:"printer_model"`          // type detected when the printer was discovered
+	Source    string    `json:"source"`                 // mdns or scan
+	Added     bool      `json:"added"`                  // printer is scraped, automatically or after approval
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// candidate is a device found on the network that can be a printer
+type candidate struct {
+	address string
+	name    string
+	source  string
+}
+
+var (
+	// defaultInterval is how often printers are discovered when interval is not configured
+	defaultInterval = 5 * time.Minute
+
+	// mdnsTimeout is how long responses to mDNS query are collected
+	mdnsTimeout = 3 * time.Second
+
+	discoveryMutex sync.Mutex
+	discovered     = map[string]*DiscoveredPrinter{} // printer address -> discovered printer
+
+	configuration config.Config
+)
+
+// Run discovers printers in configured interval, it never returns
+func Run(config config.Config) {
+	configuration = config
+
+	interval := time.Duration(configuration.Exporter.Discovery.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	for {
+		Discover()
+		time.Sleep(interval)
+	}
+}
+
+// Discover browses mDNS and scans configured subnets once, found PrusaLink printers that are not scraped yet
+// are added when auto_add is set, otherwise they wait for approval
+func Discover() {
+	discovery := configuration.Exporter.Discovery
+	candidates := []candidate{}
+
+	if discovery.MDNS {
+		services := discovery.Services
+		if len(services) == 0 {
+			services = defaultServices
+		}
+		found, err := browseMDNS(services, mdnsTimeout)
+		if err != nil {
+			log.Error().Msg("Error while browsing mDNS - " + err.Error())
+		}
+		candidates = append(candidates, found...)
+	}
+
+	if len(discovery.Subnets) > 0 {
+		found, errs := scanSubnets(discovery.Subnets)
+		for _, err := range errs {
+			log.Error().Msg("Error while scanning subnet - " + err.Error())
+		}
+		candidates = append(candidates, found...)
+	}
+
+	checked := map[string]bool{}
+	for _, candidate := range candidates {
+		if checked[candidate.address] || isScraped(candidate.address) {
+			continue
+		}
+		checked[candidate.address] = true
+		identify(candidate)
+	}
+}
+
+// isScraped checks if the exporter already scrapes printer at the address, port 80 is the same as no port
+func isScraped(address string) bool {
+	for _, printer := range prusalink.GetPrinters() {
+		if strings.TrimSuffix(printer.Address, ":80") == strings.TrimSuffix(address, ":80") {
+			return true
+		}
+	}
+	return false
+}
+
+// newPrinter returns printer at the address with credentials, group and labels of the discovery profile
+func newPrinter(address string, name string) config.Printers {
+	printer := configuration.Exporter.Discovery.Profile
+	printer.Address = address
+	printer.Type = "" // type is detected as for configured printers without type
+	if printer.Name == "" {
+		printer.Name = name
+	}
+	return printer
+}
+
+// identify checks if the candidate is PrusaLink printer and records it as discovered
+func identify(candidate candidate) {
+	printer := newPrinter(candidate.address, candidate.name)
+
+	if ok, err := prusalink.ProbePrinter(printer); err != nil || !ok {
+		log.Trace().Msg("Device at " + candidate.address + " is not a printer")
+		return
+	}
+	printerType, err := prusalink.GetPrinterType(context.Background(), printer)
+	if err != nil || printerType == "unknown" {
+		log.Debug().Msg("Device at " + candidate.address + " is not a PrusaLink printer")
+		return
+	}
+
+	discoveryMutex.Lock()
+	defer discoveryMutex.Unlock()
+
+	now := time.Now()
+	found := discovered[candidate.address]
+	if found == nil {
+		found = &DiscoveredPrinter{Address: candidate.address, FirstSeen: now}
+		discovered[candidate.address] = found
+		log.Info().Msg(printerType + " discovered at " + candidate.address + " by " + candidate.source)
+	}
+	found.Model, found.Source, found.LastSeen = printerType, candidate.source, now
+	if candidate.name != "" {
+		found.Name = candidate.name
+	}
+
+	if configuration.Exporter.Discovery.AutoAdd && !found.Added {
+		prusalink.AddPrinter(printer)
+		found.Added = true
+	}
+}
+
+// approve adds discovered printer with the address to scraped printers, it returns false when there is no such printer
+func approve(address string) bool {
+	discoveryMutex.Lock()
+	defer discoveryMutex.Unlock()
+
+	found := discovered[address]
+	if found == nil {
+		return false
+	}
+	if !found.Added {
+		prusalink.AddPrinter(newPrinter(found.Address, found.Name))
+		found.Added = true
+	}
+	return true
+}
+
+// GetDiscovered returns discovered printers sorted by address
+func GetDiscovered() []DiscoveredPrinter {
+	discoveryMutex.Lock()
+	defer discoveryMutex.Unlock()
+
+	printers := []DiscoveredPrinter{}
+	for _, printer := range discovered {
+		printers = append(printers, *printer)
+	}
+	sort.Slice(printers, func(i, j int) bool {
+		return printers[i].Address < printers[j].Address
+	})
+	return printers
+}
+
+// Handler returns handler of discovered printers at the given prefix - e.g. /discovery. GET <prefix>/ lists discovered
+// printers, POST <prefix>/<address> approves the printer and it is scraped from then on.
+func Handler(prefix string) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	return http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address := strings.Trim(r.URL.Path, "/")
+
+		if address == "" {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(GetDiscovered()); err != nil {
+				log.Error().Msg("Error while writing JSON response - " + err.Error())
+			}
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			http.Error(w, "use POST "+prefix+"/<address> to approve discovered printer", http.StatusMethodNotAllowed)
+			return
+		}
+		if !approve(address) {
+			http.Error(w, "unknown discovered printer "+address, http.StatusNotFound)
+			return
+		}
+		log.Info().Msg("Discovered printer at " + address + " approved")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
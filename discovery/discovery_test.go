@@ -0,0 +1,189 @@
+package discovery
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/pstrobl96/prusa_exporter/prusalink"
+)
+
+// newPrusaLink returns a server that answers as PrusaLink of MK4 with the API key, other devices answer only / and ask
+// for credentials elsewhere without challenge of PrusaLink, the API key must never be sent to them
+func newPrusaLink(t *testing.T, printer bool) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/":
+			w.Write([]byte("<html></html>"))
+		case !printer:
+			if r.Header.Get("X-Api-Key") != "" {
+				t.Errorf("API key is sent to device without PrusaLink at %s", r.URL.Path)
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Header.Get("X-Api-Key") != "secret":
+			w.Header().Set("WWW-Authenticate", `ApiKey realm="Printer API"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/api/version":
+			w.Write([]byte(`{"api": "2.0.0", "hostname": "PrusaMK4", "server": "2.1.2", "text": "PrusaLink"}`))
+		case r.URL.Path == "/api/v1/status":
+			w.Write([]byte(`{"printer": {"state": "IDLE"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// setupScan points scan of 127.0.0.1/32 to port of the server and resets discovered printers
+func setupScan(t *testing.T, server *httptest.Server, autoAdd bool) string {
+	address, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(address.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scanPort = port
+	discovered = map[string]*DiscoveredPrinter{}
+	t.Cleanup(func() { scanPort = 80 })
+
+	var cfg config.Config
+	cfg.Exporter.ScrapeTimeout = 1000
+	cfg.Exporter.Discovery.Enabled = true
+	cfg.Exporter.Discovery.Subnets = []string{"127.0.0.1/32"}
+	cfg.Exporter.Discovery.AutoAdd = autoAdd
+	cfg.Exporter.Discovery.Profile = config.Printers{Apikey: "secret", Group: "farm"}
+	prusalink.NewCollector(cfg)
+	configuration = cfg
+
+	return address.Host
+}
+
+func TestGetHosts(t *testing.T) {
+	tests := []struct {
+		cidr  string
+		hosts []string
+		valid bool
+	}{
+		{"192.168.20.0/30", []string{"192.168.20.1", "192.168.20.2"}, true},
+		{"192.168.20.12/31", []string{"192.168.20.12", "192.168.20.13"}, true},
+		{"192.168.20.12/32", []string{"192.168.20.12"}, true},
+		{"192.168.20.12/24", nil, true}, // host bits are ignored
+		{"10.0.0.0/8", nil, false},
+		{"fd00::/120", nil, false},
+		{"printers", nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.cidr, func(t *testing.T) {
+			hosts, err := getHosts(test.cidr)
+			if (err == nil) != test.valid {
+				t.Fatalf("getHosts(%s) returned %v, want valid %v", test.cidr, err, test.valid)
+			}
+			if test.cidr == "192.168.20.12/24" {
+				if len(hosts) != 254 || hosts[0].String() != "192.168.20.1" || hosts[253].String() != "192.168.20.254" {
+					t.Errorf("hosts of %s are %d from %s to %s", test.cidr, len(hosts), hosts[0], hosts[len(hosts)-1])
+				}
+				return
+			}
+			got := []string{}
+			for _, host := range hosts {
+				got = append(got, host.String())
+			}
+			if test.valid && len(got) != len(test.hosts) {
+				t.Fatalf("hosts of %s are %v, want %v", test.cidr, got, test.hosts)
+			}
+			for i := range test.hosts {
+				if got[i] != test.hosts[i] {
+					t.Errorf("hosts of %s are %v, want %v", test.cidr, got, test.hosts)
+				}
+			}
+		})
+	}
+}
+
+func TestDiscoverAutoAdd(t *testing.T) {
+	address := setupScan(t, newPrusaLink(t, true), true)
+
+	Discover()
+
+	printers := GetDiscovered()
+	if len(printers) != 1 || printers[0].Address != address || printers[0].Model != "MK4" || printers[0].Source != "scan" || !printers[0].Added {
+		t.Fatalf("discovered printers are %+v", printers)
+	}
+
+	scraped := prusalink.GetPrinters()
+	if len(scraped) != 1 || scraped[0].Address != address || scraped[0].Apikey != "secret" || scraped[0].Group != "farm" {
+		t.Fatalf("scraped printers are %+v", scraped)
+	}
+
+	Discover() // scraped printer is not discovered again
+	if printers := GetDiscovered(); len(printers) != 1 || !printers[0].LastSeen.Equal(printers[0].FirstSeen) {
+		t.Errorf("scraped printer is discovered again %+v", printers)
+	}
+}
+
+func TestDiscoverNotPrinter(t *testing.T) {
+	setupScan(t, newPrusaLink(t, false), true)
+
+	Discover()
+
+	if printers := GetDiscovered(); len(printers) != 0 {
+		t.Errorf("device without PrusaLink is discovered %+v", printers)
+	}
+	if printers := prusalink.GetPrinters(); len(printers) != 0 {
+		t.Errorf("device without PrusaLink is scraped %+v", printers)
+	}
+}
+
+func TestHandlerApprove(t *testing.T) {
+	address := setupScan(t, newPrusaLink(t, true), false)
+	Discover()
+
+	if printers := prusalink.GetPrinters(); len(printers) != 0 {
+		t.Fatalf("printer is scraped without approval %+v", printers)
+	}
+
+	handler := Handler("/discovery")
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/discovery/", nil))
+	var printers []DiscoveredPrinter
+	if err := json.Unmarshal(recorder.Body.Bytes(), &printers); err != nil {
+		t.Fatal(err)
+	}
+	if len(printers) != 1 || printers[0].Address != address || printers[0].Added {
+		t.Fatalf("discovered printers are %+v", printers)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/discovery/" + address, http.StatusMethodNotAllowed},
+		{http.MethodPost, "/discovery/192.168.20.99", http.StatusNotFound},
+		{http.MethodPost, "/discovery/" + address, http.StatusNoContent},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, nil))
+		if recorder.Code != test.status {
+			t.Errorf("%s %s returned %d, want %d", test.method, test.path, recorder.Code, test.status)
+		}
+	}
+
+	if scraped := prusalink.GetPrinters(); len(scraped) != 1 || scraped[0].Address != address {
+		t.Errorf("approved printer is not scraped %+v", scraped)
+	}
+	if printers := GetDiscovered(); len(printers) != 1 || !printers[0].Added {
+		t.Errorf("approved printer is not marked as added %+v", printers)
+	}
+}
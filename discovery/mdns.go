@@ -0,0 +1,226 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	typeA   = 1
+	typePTR = 12
+	typeSRV = 33
+
+	classIN = 1
+
+	// unicastResponse is the top bit of question class, responders answer such question directly to the sender
+	unicastResponse = 0x8000
+)
+
+var (
+	// mdnsAddress is multicast address of mDNS
+	mdnsAddress = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+	// defaultServices are DNS-SD services browsed when services are not configured, PrusaLink serves HTTP
+	defaultServices = []string{"_http._tcp.local"}
+
+	errMalformed = errors.New("malformed mDNS message")
+)
+
+// srvRecord is host and port of DNS-SD service instance
+type srvRecord struct {
+	target string
+	port   uint16
+}
+
+// mdnsRecords are records of all mDNS responses received during one browse, names are lowercase without trailing dot
+// except instances that keep their case for names of printers
+type mdnsRecords struct {
+	ptr map[string][]string  // service -> instances
+	srv map[string]srvRecord // instance -> host and port
+	a   map[string]net.IP    // host -> IPv4 address
+}
+
+// newMDNSRecords returns empty records
+func newMDNSRecords() *mdnsRecords {
+	return &mdnsRecords{ptr: map[string][]string{}, srv: map[string]srvRecord{}, a: map[string]net.IP{}}
+}
+
+// browseMDNS sends query for the services and collects responses until the timeout. Query is sent from ephemeral port,
+// so responders answer it with unicast and the exporter does not need to listen on port 5353.
+func browseMDNS(services []string, timeout time.Duration) ([]candidate, error) {
+	connection, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, err
+	}
+	defer connection.Close()
+
+	if _, err := connection.WriteToUDP(newQuery(services), mdnsAddress); err != nil {
+		return nil, err
+	}
+	if err := connection.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	records := newMDNSRecords()
+	buffer := make([]byte, 9000)
+	for {
+		n, _, err := connection.ReadFromUDP(buffer)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			return nil, err
+		}
+		if err := records.parse(buffer[:n]); err != nil {
+			continue // other devices can send anything to the port
+		}
+	}
+
+	return records.candidates(services), nil
+}
+
+// newQuery returns mDNS query with PTR question for every service
+func newQuery(services []string) []byte {
+	message := make([]byte, 12)
+	binary.BigEndian.PutUint16(message[4:], uint16(len(services)))
+
+	for _, service := range services {
+		message = appendName(message, service)
+		message = binary.BigEndian.AppendUint16(message, typePTR)
+		message = binary.BigEndian.AppendUint16(message, classIN|unicastResponse)
+	}
+	return message
+}
+
+// appendName appends the name in DNS wire format without compression
+func appendName(message []byte, name string) []byte {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		message = append(message, byte(len(label)))
+		message = append(message, label...)
+	}
+	return append(message, 0)
+}
+
+// readName reads possibly compressed name at the offset, it returns the name and offset after the name
+func readName(message []byte, offset int) (string, int, error) {
+	labels := []string{}
+	end := -1 // offset after the name, pointer ends the name where it is
+
+	for jumps := 0; ; jumps++ {
+		if offset >= len(message) || jumps > 64 {
+			return "", 0, errMalformed
+		}
+
+		length := int(message[offset])
+		switch {
+		case length == 0:
+			if end < 0 {
+				end = offset + 1
+			}
+			return strings.Join(labels, "."), end, nil
+		case length&0xc0 == 0xc0:
+			if offset+1 >= len(message) {
+				return "", 0, errMalformed
+			}
+			if end < 0 {
+				end = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(message[offset:]) & 0x3fff)
+		default:
+			if offset+1+length > len(message) {
+				return "", 0, errMalformed
+			}
+			labels = append(labels, string(message[offset+1:offset+1+length]))
+			offset += 1 + length
+		}
+	}
+}
+
+// parse adds PTR, SRV and A records of the response to records, questions are skipped
+func (records *mdnsRecords) parse(message []byte) error {
+	if len(message) < 12 || message[2]&0x80 == 0 { // only responses
+		return errMalformed
+	}
+
+	questions := int(binary.BigEndian.Uint16(message[4:]))
+	count := int(binary.BigEndian.Uint16(message[6:])) + int(binary.BigEndian.Uint16(message[8:])) + int(binary.BigEndian.Uint16(message[10:]))
+
+	offset := 12
+	for i := 0; i < questions; i++ {
+		_, next, err := readName(message, offset)
+		if err != nil {
+			return err
+		}
+		offset = next + 4
+	}
+
+	for i := 0; i < count; i++ {
+		name, next, err := readName(message, offset)
+		if err != nil {
+			return err
+		}
+		if next+10 > len(message) {
+			return errMalformed
+		}
+		kind := binary.BigEndian.Uint16(message[next:])
+		length := int(binary.BigEndian.Uint16(message[next+8:]))
+		data := next + 10
+		if data+length > len(message) {
+			return errMalformed
+		}
+
+		switch kind {
+		case typePTR:
+			instance, _, err := readName(message, data)
+			if err != nil {
+				return err
+			}
+			records.ptr[strings.ToLower(name)] = append(records.ptr[strings.ToLower(name)], instance)
+		case typeSRV:
+			if length < 7 {
+				return errMalformed
+			}
+			target, _, err := readName(message, data+6)
+			if err != nil {
+				return err
+			}
+			records.srv[strings.ToLower(name)] = srvRecord{target: strings.ToLower(target), port: binary.BigEndian.Uint16(message[data+4:])}
+		case typeA:
+			if length == 4 {
+				records.a[strings.ToLower(name)] = net.IP(append([]byte{}, message[data:data+4]...))
+			}
+		}
+		offset = data + length
+	}
+	return nil
+}
+
+// candidates returns instances of the services with known IPv4 address, port 80 is not part of the address
+func (records *mdnsRecords) candidates(services []string) []candidate {
+	candidates := []candidate{}
+	for _, service := range services {
+		for _, instance := range records.ptr[strings.ToLower(strings.TrimSuffix(service, "."))] {
+			srv, ok := records.srv[strings.ToLower(instance)]
+			if !ok {
+				continue
+			}
+			ip, ok := records.a[srv.target]
+			if !ok {
+				continue
+			}
+
+			address := ip.String()
+			if srv.port != 80 {
+				address = net.JoinHostPort(address, strconv.Itoa(int(srv.port)))
+			}
+			name, _, _ := strings.Cut(instance, ".")
+			candidates = append(candidates, candidate{address: address, name: name, source: "mdns"})
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,75 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// appendRecord appends resource record with the name, type and data to the message
+func appendRecord(message []byte, name []byte, kind uint16, data []byte) []byte {
+	message = append(message, name...)
+	message = binary.BigEndian.AppendUint16(message, kind)
+	message = binary.BigEndian.AppendUint16(message, classIN)
+	message = binary.BigEndian.AppendUint32(message, 120)
+	message = binary.BigEndian.AppendUint16(message, uint16(len(data)))
+	return append(message, data...)
+}
+
+// newResponse returns mDNS response with two printers - one with A record and one without it, instance names of the
+// first printer are compressed by pointer to the question
+func newResponse() []byte {
+	message := []byte{0, 0, 0x84, 0, 0, 1, 0, 5, 0, 0, 0, 0}
+	message = appendName(message, "_http._tcp.local")
+	message = binary.BigEndian.AppendUint16(message, typePTR)
+	message = binary.BigEndian.AppendUint16(message, classIN)
+
+	service := []byte{0xc0, 12} // pointer to the name of the question
+	instance := append(append([]byte{9}, "Prusa MK4"...), service...)
+	message = appendRecord(message, service, typePTR, instance)
+	message = appendRecord(message, service, typePTR, appendName(nil, "Prusa XL._http._tcp.local"))
+
+	srv := []byte{0, 0, 0, 0, 0, 80}
+	message = appendRecord(message, instance, typeSRV, appendName(srv, "prusa-mk4.local"))
+	srv = []byte{0, 0, 0, 0, 0x1f, 0x90}
+	message = appendRecord(message, appendName(nil, "Prusa XL._http._tcp.local"), typeSRV, appendName(srv, "prusa-xl.local"))
+
+	return appendRecord(message, appendName(nil, "Prusa-MK4.local"), typeA, []byte{192, 168, 20, 12})
+}
+
+func TestParseMDNS(t *testing.T) {
+	records := newMDNSRecords()
+	if err := records.parse(newResponse()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []candidate{{address: "192.168.20.12", name: "Prusa MK4", source: "mdns"}}
+	if got := records.candidates([]string{"_http._tcp.local."}); !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates are %v, want %v", got, want)
+	}
+
+	records.a["prusa-xl.local"] = []byte{192, 168, 20, 13}
+	want = append(want, candidate{address: "192.168.20.13:8080", name: "Prusa XL", source: "mdns"})
+	if got := records.candidates([]string{"_http._tcp.local"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates are %v, want %v", got, want)
+	}
+}
+
+func TestParseMDNSMalformed(t *testing.T) {
+	response := newResponse()
+
+	tests := map[string][]byte{
+		"query":         newQuery([]string{"_http._tcp.local"}),
+		"truncated":     response[:len(response)-3],
+		"pointer loop":  {0, 0, 0x84, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0xc0, 12},
+		"short message": {0, 0, 0x84},
+	}
+
+	for name, message := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := newMDNSRecords().parse(message); err == nil {
+				t.Error("malformed message is parsed")
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// maxScanHosts is number of hosts of the largest subnet that is scanned, larger subnets would take too long
+	maxScanHosts = 65536
+
+	// scanPort is port where PrusaLink serves its web interface and API
+	scanPort = 80
+
+	// scanTimeout is how long connection to one host is tried, printers on local network answer much sooner
+	scanTimeout = time.Second
+
+	// scanWorkers is number of hosts connected at the same time
+	scanWorkers = 64
+)
+
+// getHosts returns addresses of hosts of the IPv4 subnet, network and broadcast addresses are skipped for subnets larger than /31
+func getHosts(cidr string) ([]net.IP, error) {
+	_, subnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	network := subnet.IP.To4()
+	if network == nil {
+		return nil, errors.New("subnet " + cidr + " is not IPv4")
+	}
+
+	ones, bits := subnet.Mask.Size()
+	size := 1 << (bits - ones)
+	if size > maxScanHosts {
+		return nil, errors.New("subnet " + cidr + " has more than " + strconv.Itoa(maxScanHosts) + " hosts")
+	}
+
+	first, last := 0, size
+	if size > 2 {
+		first, last = 1, size-1
+	}
+
+	hosts := []net.IP{}
+	start := binary.BigEndian.Uint32(network)
+	for i := first; i < last; i++ {
+		host := make(net.IP, 4)
+		binary.BigEndian.PutUint32(host, start+uint32(i))
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// scanSubnets returns hosts of the subnets that accept connections on scanPort, invalid subnets are returned as errors
+func scanSubnets(subnets []string) ([]candidate, []error) {
+	hosts := []net.IP{}
+	errs := []error{}
+	for _, subnet := range subnets {
+		subnetHosts, err := getHosts(subnet)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		hosts = append(hosts, subnetHosts...)
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	candidates := []candidate{}
+	queue := make(chan net.IP)
+
+	for i := 0; i < scanWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for host := range queue {
+				address := net.JoinHostPort(host.String(), strconv.Itoa(scanPort))
+				connection, err := net.DialTimeout("tcp", address, scanTimeout)
+				if err != nil {
+					continue
+				}
+				connection.Close()
+
+				if scanPort == 80 {
+					address = host.String()
+				}
+				mutex.Lock()
+				candidates = append(candidates, candidate{address: address, source: "scan"})
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for _, host := range hosts {
+		queue <- host
+	}
+	close(queue)
+	wg.Wait()
+
+	return candidates, errs
+}
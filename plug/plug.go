@@ -0,0 +1,76 @@
+package plug
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// paths are status paths of supported smart plugs by their type
+	paths = map[string]string{
+		"shelly":  "/rpc/Switch.GetStatus?id=0",
+		"tasmota": "/cm?cmnd=Status%208",
+	}
+)
+
+// Client is a client of HTTP API of a smart plug that measures power of the printer - Shelly (Gen2 and newer) or Tasmota
+type Client struct {
+	kind    string
+	address string
+	client  *http.Client
+}
+
+// NewClient returns a new client of the smart plug of the given type at the address, http:// is added when address has no scheme
+func NewClient(kind string, address string, timeout time.Duration) (*Client, error) {
+	if paths[kind] == "" {
+		return nil, errors.New("unknown smart plug type " + kind + ", use shelly or tasmota")
+	}
+
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	return &Client{
+		kind:    kind,
+		address: strings.TrimSuffix(address, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// GetPower returns active power measured by the plug in W
+func (c *Client) GetPower() (float64, error) {
+	response, err := c.get(paths[c.kind])
+	if err != nil {
+		return 0, err
+	}
+
+	switch c.kind {
+	case "tasmota":
+		var status TasmotaStatus
+		err = json.Unmarshal(response, &status)
+		return status.StatusSNS.Energy.Power, err
+	default:
+		var status ShellyStatus
+		err = json.Unmarshal(response, &status)
+		return status.APower, err
+	}
+}
+
+// get sends GET request to the path of the plug
+func (c *Client) get(path string) ([]byte, error) {
+	res, err := c.client.Get(c.address + path)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New("smart plug returned " + res.Status + " for " + path)
+	}
+
+	return io.ReadAll(res.Body)
+}
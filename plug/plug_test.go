@@ -0,0 +1,36 @@
+package plug
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetPower(t *testing.T) {
+	for _, kind := range []string{"shelly", "tasmota"} {
+		t.Run(kind, func(t *testing.T) {
+			standIn := NewStandIn(kind, 120.5)
+			server := httptest.NewServer(standIn)
+			t.Cleanup(server.Close)
+
+			client, err := NewClient(kind, strings.TrimPrefix(server.URL, "http://"), time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, err := client.GetPower(); err != nil || got != 120.5 {
+				t.Errorf("got %v with error %v, want 120.5", got, err)
+			}
+
+			standIn.SetPower(8)
+			if got, err := client.GetPower(); err != nil || got != 8 {
+				t.Errorf("got %v with error %v, want 8", got, err)
+			}
+		})
+	}
+
+	if _, err := NewClient("unknown", "127.0.0.1", time.Second); err == nil {
+		t.Error("unknown plug type returned no error")
+	}
+}
@@ -0,0 +1,57 @@
+package plug
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// StandIn is a local stand-in of a smart plug serving status of Shelly or Tasmota for tests and demos
+type StandIn struct {
+	kind  string
+	mutex sync.Mutex
+	power float64
+}
+
+// NewStandIn returns a stand-in of the smart plug of the given type measuring the given power in W
+func NewStandIn(kind string, power float64) *StandIn {
+	return &StandIn{kind: kind, power: power}
+}
+
+// SetPower replaces power measured by the stand-in
+func (s *StandIn) SetPower(power float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.power = power
+}
+
+// ServeHTTP implements http.Handler, only status path of the plug type is served
+func (s *StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path, query, _ := strings.Cut(paths[s.kind], "?")
+	if r.URL.Path != path || r.URL.RawQuery != query {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mutex.Lock()
+	power := s.power
+	s.mutex.Unlock()
+
+	var status any
+	switch s.kind {
+	case "tasmota":
+		var tasmota TasmotaStatus
+		tasmota.StatusSNS.Energy.Power = power
+		tasmota.StatusSNS.Energy.Voltage = 230
+		tasmota.StatusSNS.Energy.Current = power / 230
+		status = tasmota
+	default:
+		shelly := ShellyStatus{Output: true, APower: power, Voltage: 230, Current: power / 230}
+		status = shelly
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
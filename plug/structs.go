@@ -0,0 +1,26 @@
+package plug
+
+// ShellyStatus is a struct that contains status of the switch from path /rpc/Switch.GetStatus of Shelly Gen2 and newer
+type ShellyStatus struct {
+	ID      int     `json:"id"`
+	Output  bool    `json:"output"`
+	APower  float64 `json:"apower"`  // active power in W
+	Voltage float64 `json:"voltage"` // in V
+	Current float64 `json:"current"` // in A
+	AEnergy struct {
+		Total float64 `json:"total"` // energy since boot of the plug in Wh
+	} `json:"aenergy"`
+}
+
+// TasmotaStatus is a struct that contains sensors of Tasmota from path /cm?cmnd=Status%208
+type TasmotaStatus struct {
+	StatusSNS struct {
+		Time   string `json:"Time"`
+		Energy struct {
+			Total   float64 `json:"Total"`   // in kWh
+			Power   float64 `json:"Power"`   // active power in W
+			Voltage float64 `json:"Voltage"` // in V
+			Current float64 `json:"Current"` // in A
+		} `json:"ENERGY"`
+	} `json:"StatusSNS"`
+}
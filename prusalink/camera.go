@@ -0,0 +1,226 @@
+package prusalink
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/rs/zerolog/log"
+)
+
+// CameraSnapshot is the latest snapshot of the camera connected to PrusaLink together with its download statistics
+type CameraSnapshot struct {
+	Printer     string            `json:"printer"`
+	Address     string            `json:"printer_address"`
+	CameraID    string            `json:"camera_id"`
+	CameraName  string            `json:"camera_name"`
+	ContentType string            `json:"content_type,omitempty"`
+	Size        int               `json:"size"`
+	Time        time.Time         `json:"time"`     // when the snapshot was downloaded, zero when no snapshot was downloaded yet
+	Failures    float64           `json:"failures"` // number of failed downloads since start of the exporter
+	LastError   string            `json:"last_error,omitempty"`
+	Snapshot    string            `json:"snapshot,omitempty"` // URL path of the snapshot on the exporter
+	Labels      map[string]string `json:"labels,omitempty"`
+	image       []byte
+}
+
+var (
+	// defaultCameraInterval is how often snapshots are downloaded when interval is not configured
+	defaultCameraInterval = 30 * time.Second
+
+	cameraMutex     sync.Mutex
+	cameraSnapshots = map[string]*CameraSnapshot{} // printer address + "/" + camera id -> latest snapshot
+)
+
+// RunCameraSnapshots downloads snapshots of all cameras of all printers in configured interval, it never returns
+func RunCameraSnapshots() {
+	interval := time.Duration(configuration.Exporter.Prusalink.Cameras.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultCameraInterval
+	}
+
+	for {
+		UpdateCameraSnapshots()
+		time.Sleep(interval)
+	}
+}
+
+// UpdateCameraSnapshots downloads snapshots of all cameras of all printers once, only printers with PrusaLink on Raspberry Pi have cameras
+func UpdateCameraSnapshots() {
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for _, s := range GetPrinters() {
+		wg.Add(1)
+		go func(s config.Printers) {
+			defer wg.Done()
+
+			if s.Type == "" {
+				printerType, err := GetPrinterType(ctx, s)
+				if err != nil {
+					log.Error().Msg("Error while probing printer at " + s.Address + " - " + err.Error())
+					return
+				}
+				s.Type = printerType
+			}
+
+			if printerBoards[s.Type] != "einsy" {
+				return
+			}
+
+			cameras, err := GetCameras(ctx, s)
+			if err != nil {
+				log.Error().Msg("Error while scraping cameras endpoint at " + s.Address + " - " + err.Error())
+				return
+			}
+
+			for _, camera := range cameras.CameraList {
+				if camera.Connected {
+					updateCameraSnapshot(ctx, s, camera.CameraID, camera.Config.Name)
+				}
+			}
+		}(s)
+	}
+	wg.Wait()
+}
+
+// updateCameraSnapshot downloads snapshot of one camera, failed download keeps the previous snapshot
+func updateCameraSnapshot(ctx context.Context, printer config.Printers, cameraID string, cameraName string) {
+	image, contentType, err := getCameraSnapshot(ctx, printer, cameraID)
+
+	cameraMutex.Lock()
+	defer cameraMutex.Unlock()
+
+	key := printer.Address + "/" + cameraID
+	snapshot := cameraSnapshots[key]
+	if snapshot == nil {
+		snapshot = &CameraSnapshot{Printer: GetPrinterID(printer), Address: printer.Address, CameraID: cameraID,
+			Labels: configuration.GetPrinterLabels(printer)}
+		cameraSnapshots[key] = snapshot
+	}
+	snapshot.CameraName = cameraName
+
+	if err != nil {
+		log.Error().Msg("Error while downloading snapshot of camera " + cameraID + " at " + printer.Address + " - " + err.Error())
+		snapshot.Failures++
+		snapshot.LastError = err.Error()
+		return
+	}
+
+	snapshot.image = image
+	snapshot.Size = len(image)
+	snapshot.ContentType = contentType
+	snapshot.Time = time.Now()
+	snapshot.LastError = ""
+}
+
+// getCameraSnapshot downloads the current snapshot of the camera from PrusaLink
+func getCameraSnapshot(ctx context.Context, printer config.Printers, cameraID string) ([]byte, string, error) {
+	res, err := getPrinterResponse(ctx, "/api/v1/cameras/"+url.PathEscape(cameraID)+"/snap", printer)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, "", errors.New("snapshot returned " + res.Status)
+	}
+
+	image, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(image) == 0 {
+		return nil, "", errors.New("snapshot is empty")
+	}
+
+	return image, res.Header.Get("Content-Type"), nil
+}
+
+// GetCameraSnapshots returns snapshots of all cameras of the printer with the given address - empty address returns cameras of all printers
+func GetCameraSnapshots(address string) []CameraSnapshot {
+	cameraMutex.Lock()
+	defer cameraMutex.Unlock()
+
+	snapshots := []CameraSnapshot{}
+	for _, snapshot := range cameraSnapshots {
+		if address == "" || snapshot.Address == address {
+			snapshots = append(snapshots, *snapshot)
+		}
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Address != snapshots[j].Address {
+			return snapshots[i].Address < snapshots[j].Address
+		}
+		return snapshots[i].CameraID < snapshots[j].CameraID
+	})
+
+	return snapshots
+}
+
+// CameraHandler returns handler serving the latest camera snapshots at the given prefix - e.g. /cameras.
+// <prefix>/ lists all cameras and <prefix>/<printer>/<camera_id> returns the latest snapshot of the camera.
+// Handler requires basic authentication when username is set in cameras configuration.
+func CameraHandler(prefix string) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	return http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !cameraAuthorized(r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="prusa_exporter cameras"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		path := strings.Trim(r.URL.Path, "/")
+
+		if path == "" {
+			snapshots := GetCameraSnapshots("")
+			for i := range snapshots {
+				if !snapshots[i].Time.IsZero() {
+					snapshots[i].Snapshot = prefix + "/" + url.PathEscape(snapshots[i].Printer) + "/" + url.PathEscape(snapshots[i].CameraID)
+				}
+			}
+			writeJSON(w, snapshots)
+			return
+		}
+
+		id, cameraID, _ := strings.Cut(path, "/")
+
+		for _, snapshot := range GetCameraSnapshots("") {
+			if (snapshot.Printer == id || snapshot.Address == id) && snapshot.CameraID == cameraID {
+				if snapshot.Time.IsZero() {
+					http.Error(w, "camera "+cameraID+" has no snapshot yet", http.StatusNotFound)
+					return
+				}
+				w.Header().Set("Content-Type", snapshot.ContentType)
+				w.Header().Set("Last-Modified", snapshot.Time.UTC().Format(http.TimeFormat))
+				w.Write(snapshot.image)
+				return
+			}
+		}
+
+		http.NotFound(w, r)
+	}))
+}
+
+// cameraAuthorized checks basic authentication of the request against cameras configuration
+func cameraAuthorized(r *http.Request) bool {
+	cameras := configuration.Exporter.Prusalink.Cameras
+	if cameras.Username == "" {
+		return true
+	}
+
+	username, password, ok := r.BasicAuth()
+	return ok &&
+		subtle.ConstantTimeCompare([]byte(username), []byte(cameras.Username)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(password), []byte(cameras.Password)) == 1
+}
@@ -0,0 +1,119 @@
+package prusalink
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/pstrobl96/prusa_exporter/simulator"
+)
+
+func TestCameraSnapshots(t *testing.T) {
+	printer, err := simulator.New(simulator.Options{
+		Model:   "I3MK3S",
+		Cameras: 2,
+		Faults:  []simulator.Fault{{Path: "/api/v1/cameras/camera2/snap", Status: http.StatusServiceUnavailable, Probability: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(printer)
+	t.Cleanup(server.Close)
+
+	address, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg config.Config
+	cfg.Exporter.ScrapeTimeout = 1000
+	cfg.Exporter.Prusalink.Cameras.Enabled = true
+	cfg.Exporter.Prusalink.Cameras.Username = "viewer"
+	cfg.Exporter.Prusalink.Cameras.Password = "secret"
+	cfg.Printers = []config.Printers{{Address: address.Host, Name: "farm 1", Type: "I3MK3S"}}
+	collector := NewCollector(cfg)
+
+	cameraMutex.Lock()
+	cameraSnapshots = map[string]*CameraSnapshot{}
+	cameraMutex.Unlock()
+
+	UpdateCameraSnapshots()
+	UpdateCameraSnapshots()
+
+	snapshots := GetCameraSnapshots(address.Host)
+	if len(snapshots) != 2 {
+		t.Fatalf("got %d cameras, want 2", len(snapshots))
+	}
+	if got := snapshots[0]; got.CameraID != "camera1" || got.Size == 0 || got.Failures != 0 || got.ContentType != "image/jpeg" {
+		t.Errorf("unexpected snapshot of working camera %+v", got)
+	}
+	if got := snapshots[1]; got.CameraID != "camera2" || !got.Time.IsZero() || got.Failures != 2 || got.LastError == "" {
+		t.Errorf("unexpected snapshot of failing camera %+v", got)
+	}
+
+	for name, want := range map[string]int{
+		"prusa_camera_snapshot_age_seconds":    1,
+		"prusa_camera_snapshot_size_bytes":     1,
+		"prusa_camera_snapshot_failures_total": 2,
+	} {
+		if got := testutil.CollectAndCount(collector, name); got != want {
+			t.Errorf("%s has %d series, want %d", name, got, want)
+		}
+	}
+
+	handler := httptest.NewServer(CameraHandler("/cameras"))
+	t.Cleanup(handler.Close)
+
+	tests := []struct {
+		path        string
+		username    string
+		status      int
+		contentType string
+	}{
+		{"/cameras/", "", http.StatusUnauthorized, ""},
+		{"/cameras/", "viewer", http.StatusOK, "application/json"},
+		{"/cameras/farm%201/camera1", "viewer", http.StatusOK, "image/jpeg"},
+		{"/cameras/" + address.Host + "/camera1", "viewer", http.StatusOK, "image/jpeg"},
+		{"/cameras/farm%201/camera2", "viewer", http.StatusNotFound, ""},
+		{"/cameras/farm%201/camera3", "viewer", http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		request, err := http.NewRequest("GET", handler.URL+test.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.username != "" {
+			request.SetBasicAuth(test.username, "secret")
+		}
+
+		res, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode != test.status {
+			t.Errorf("%s returned %d, want %d", test.path, res.StatusCode, test.status)
+		}
+		if test.contentType != "" && res.Header.Get("Content-Type") != test.contentType {
+			t.Errorf("%s returned %s, want %s", test.path, res.Header.Get("Content-Type"), test.contentType)
+		}
+
+		if test.path == "/cameras/" && test.status == http.StatusOK {
+			var list []CameraSnapshot
+			if err := json.NewDecoder(res.Body).Decode(&list); err != nil {
+				t.Fatal(err)
+			}
+			if len(list) != 2 || !strings.HasSuffix(list[0].Snapshot, "/farm%201/camera1") || list[1].Snapshot != "" {
+				t.Errorf("unexpected camera list %+v", list)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package prusalink
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/pstrobl96/prusa_exporter/connect"
+	"github.com/rs/zerolog/log"
+)
+
+// collectConnect collects metrics of printers registered in Prusa Connect, they use the same metrics as printers scraped by PrusaLink.
+// printer_address label is connect/<uuid> of the printer.
+func (collector *Collector) collectConnect(ch chan<- prometheus.Metric) {
+	settings := configuration.Exporter.Connect
+	client := connect.NewClient(settings.URL, settings.Token, settings.TeamID,
+		time.Duration(configuration.Exporter.ScrapeTimeout)*time.Millisecond)
+
+	ctx, cancel := scrapeContext()
+	defer cancel()
+
+	printers, err := client.GetPrinters(ctx)
+	if err != nil {
+		log.Error().Msg("Error while scraping Prusa Connect - " + err.Error())
+		return
+	}
+
+	for _, printer := range printers {
+		collector.emit(ch, getConnectSnapshot(printer))
+	}
+}
+
+// getConnectSnapshot returns snapshot of one printer from Prusa Connect
+func getConnectSnapshot(p connect.Printer) PrinterSnapshot {
+	s := config.Printers{Address: "connect/" + p.UUID, Name: p.Name, Type: getConnectType(p.PrinterModel)}
+
+	if p.PrinterState == "OFFLINE" || p.PrinterState == "" {
+		return PrinterSnapshot{Printer: s}
+	}
+
+	var job Job
+	if p.JobInfo != nil {
+		job.Job.File.Name = p.JobInfo.DisplayName
+		job.Job.File.Path = p.JobInfo.Path
+	}
+
+	telemetry := p.Telemetry
+
+	snapshot := newSnapshot(s, getStatePrinter(p.PrinterState), job)
+	snapshot.Info = &PrinterInfo{Firmware: p.Firmware, Name: p.Name, Location: p.Location, Serial: p.SerialNumber}
+	snapshot.Bed = &Heater{Temp: telemetry.TempBed, Target: telemetry.TargetBed}
+	snapshot.Tools = []Tool{{ID: "0", Heater: Heater{Temp: telemetry.TempNozzle, Target: telemetry.TargetNozzle}}}
+	snapshot.Fans = []Fan{{"hotend", telemetry.FanHotend}, {"print", telemetry.FanPrint}}
+	snapshot.NozzleDiameter = ref(p.NozzleDiameter)
+	snapshot.PrintSpeed = ref(telemetry.Speed / 100)
+	snapshot.Flow = ref(telemetry.Flow / 100)
+	snapshot.Material = &Material{Name: telemetry.Material, Loaded: isLoaded(telemetry.Material)}
+	snapshot.Axis = []Axis{{"x", telemetry.AxisX}, {"y", telemetry.AxisY}, {"z", telemetry.AxisZ}}
+
+	var progress, printTime, remaining float64
+	if p.JobInfo != nil {
+		progress, printTime, remaining = p.JobInfo.Progress/100, p.JobInfo.TimePrinting, p.JobInfo.TimeRemaining
+	}
+
+	// progress is ratio as in /api/job of PrusaLink, Prusa Connect reports it in percents
+	snapshot.Job.Progress = ref(progress)
+	snapshot.Job.PrintTime = ref(printTime)
+	snapshot.Job.TimeRemaining = ref(remaining)
+
+	if p.JobQueueCount != nil {
+		snapshot.QueuedJobs = ref(float64(*p.JobQueueCount))
+	}
+
+	return snapshot
+}
+
+// getConnectType returns printer type for printer_model of Prusa Connect - e.g. MK3.9 is MK39
+func getConnectType(model string) string {
+	if printerType := slicerModels[model]; printerType != "" {
+		return printerType
+	}
+	return model
+}
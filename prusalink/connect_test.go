@@ -0,0 +1,38 @@
+package prusalink
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/pstrobl96/prusa_exporter/connect"
+)
+
+func TestConnectGolden(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "connect", "printers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var printers []connect.Printer
+	if err := json.Unmarshal(body, &printers); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(connect.NewStandIn("golden-token", printers...))
+	t.Cleanup(server.Close)
+
+	fixNow(t)
+
+	var cfg config.Config
+	cfg.Exporter.ScrapeTimeout = 1000
+	cfg.Exporter.Connect.Enabled = true
+	cfg.Exporter.Connect.URL = server.URL
+	cfg.Exporter.Connect.Token = "golden-token"
+	cfg.Exporter.Connect.TeamID = 12345
+
+	compareGolden(t, filepath.Join("testdata", "golden", "connect.prom"), gatherText(t, NewCollector(cfg), nil))
+}
@@ -0,0 +1,211 @@
+package prusalink
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// JobCost is cost of one job combining filament, energy and machine time, prices are in the configured currency
+type JobCost struct {
+	Printer         string            `json:"printer"`
+	Address         string            `json:"printer_address"`
+	Model           string            `json:"printer_model"`
+	Name            string            `json:"file_name"`
+	Path            string            `json:"file_path"`
+	Material        string            `json:"material"`
+	State           string            `json:"state"` // state of the printer when the job ended, e.g. Finished
+	Started         time.Time         `json:"started"`
+	Ended           time.Time         `json:"ended"`
+	PrintingSeconds float64           `json:"printing_seconds"`
+	FilamentGrams   float64           `json:"filament_grams"` // filament of the file, only printed part of stopped jobs
+	EnergyJoules    float64           `json:"energy_joules"`
+	FilamentCost    float64           `json:"filament_cost"`
+	EnergyCost      float64           `json:"energy_cost"`
+	MachineCost     float64           `json:"machine_cost"`
+	Cost            float64           `json:"cost"`
+	Currency        string            `json:"currency,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	progress        float64           // last known progress of the job
+}
+
+var (
+	// defaultCostHistory is number of ended jobs kept when history is not configured
+	defaultCostHistory = 100
+
+	costMutex   sync.Mutex
+	costJobs    = []JobCost{}           // ended jobs, the oldest one is the first
+	currentJobs = map[string]*JobCost{} // printer address -> job that is printed
+)
+
+// parseFilamentUsed returns sum of filament used by all tools, multi tool files have comma separated list of values
+func parseFilamentUsed(value any) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case string:
+		sum := 0.0
+		for _, tool := range strings.Split(value, ",") {
+			grams, err := strconv.ParseFloat(strings.TrimSpace(tool), 64)
+			if err != nil {
+				return 0, false
+			}
+			sum += grams
+		}
+		return sum, true
+	}
+	return 0, false
+}
+
+// getMaterialPrice returns configured price of kg of the material, names of materials are case insensitive
+func getMaterialPrice(material string) (float64, bool) {
+	for name, price := range configuration.Exporter.Costs.Materials {
+		if strings.EqualFold(name, material) {
+			return price, true
+		}
+	}
+	return 0, false
+}
+
+// getCosts computes costs of the job from its filament, energy and printing time
+func (job *JobCost) getCosts() {
+	costs := configuration.Exporter.Costs
+
+	job.FilamentCost = 0
+	if price, ok := getMaterialPrice(job.Material); ok {
+		job.FilamentCost = job.FilamentGrams / 1000 * price
+	}
+	job.EnergyCost = job.EnergyJoules / 3.6e6 * costs.Electricity
+	job.MachineCost = job.PrintingSeconds / 3600 * costs.MachineRate
+	job.Cost = job.FilamentCost + job.EnergyCost + job.MachineCost
+	job.Currency = costs.Currency
+}
+
+// updateJobCost follows the job of the printer and records its cost when the job ends. Printer that is down keeps its job.
+func updateJobCost(snapshot PrinterSnapshot, energy Energy) {
+	if !snapshot.Up {
+		return
+	}
+
+	costMutex.Lock()
+	defer costMutex.Unlock()
+
+	s := snapshot.Printer
+	job := getActiveJob(snapshot)
+	current := currentJobs[s.Address]
+
+	if current != nil && (current.Name != job.Name || current.Path != job.Path) {
+		if energy.LastJob != nil && energy.LastJob.Name == current.Name && energy.LastJob.Path == current.Path {
+			current.EnergyJoules = energy.LastJob.Joules
+		}
+		if isFinished(snapshot) {
+			current.progress = 1
+		}
+		current.State = snapshot.State
+		current.Ended = snapshot.Time
+		current.FilamentGrams *= current.progress
+		current.getCosts()
+		recordJobCost(*current)
+		delete(currentJobs, s.Address)
+		current = nil
+	}
+
+	if job.Name == "" && job.Path == "" {
+		return
+	}
+
+	if current == nil {
+		current = &JobCost{Printer: GetPrinterID(s), Address: s.Address, Model: s.Type, Name: job.Name, Path: job.Path, Started: snapshot.Time,
+			Labels: configuration.GetPrinterLabels(s)}
+		currentJobs[s.Address] = current
+	}
+
+	if job.PrintTime != nil {
+		current.PrintingSeconds = *job.PrintTime
+	}
+	if job.Progress != nil {
+		current.progress = *job.Progress
+	}
+	if job.Filament != nil {
+		current.FilamentGrams = *job.Filament
+	}
+	current.Material = job.Material
+	if current.Material == "" && snapshot.Material != nil && snapshot.Material.Loaded {
+		current.Material = snapshot.Material.Name
+	}
+	if energy.Job != nil {
+		current.EnergyJoules = energy.Job.Joules
+	}
+}
+
+// recordJobCost appends the ended job to history, the oldest job is dropped when history is full.
+// It has to be called with costMutex locked.
+func recordJobCost(job JobCost) {
+	history := configuration.Exporter.Costs.History
+	if history <= 0 {
+		history = defaultCostHistory
+	}
+
+	if _, ok := getMaterialPrice(job.Material); !ok && job.FilamentGrams > 0 {
+		log.Warn().Msg("No price of material " + job.Material + " for job " + job.Name + " of printer " + job.Printer)
+	}
+
+	costJobs = append(costJobs, job)
+	if len(costJobs) > history {
+		costJobs = costJobs[len(costJobs)-history:]
+	}
+	log.Info().Msg("Job " + job.Name + " of printer " + job.Printer + " ended with cost " + strconv.FormatFloat(job.Cost, 'f', 2, 64) + " " + job.Currency)
+}
+
+// GetJobCosts returns costs of ended jobs, the latest job is the first
+func GetJobCosts() []JobCost {
+	costMutex.Lock()
+	defer costMutex.Unlock()
+
+	jobs := make([]JobCost, 0, len(costJobs))
+	for i := len(costJobs) - 1; i >= 0; i-- {
+		jobs = append(jobs, costJobs[i])
+	}
+	return jobs
+}
+
+// getLastJobCosts returns cost of the last ended job of the printer with the given address by material
+func getLastJobCosts(address string) map[string]float64 {
+	costMutex.Lock()
+	defer costMutex.Unlock()
+
+	costs := map[string]float64{}
+	for _, job := range costJobs {
+		if job.Address == address {
+			costs[job.Material] = job.Cost
+		}
+	}
+	return costs
+}
+
+// JobsHandler returns handler of job costs at the given prefix - e.g. /api/jobs. GET <prefix>/ lists costs of ended jobs
+// of all printers, GET <prefix>/<printer> lists jobs of one printer, where printer is its name or address.
+func JobsHandler(prefix string) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	return http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Trim(r.URL.Path, "/")
+
+		jobs := GetJobCosts()
+		if id != "" {
+			filtered := []JobCost{}
+			for _, job := range jobs {
+				if job.Printer == id || job.Address == id {
+					filtered = append(filtered, job)
+				}
+			}
+			jobs = filtered
+		}
+
+		writeJSON(w, jobs)
+	}))
+}
@@ -0,0 +1,151 @@
+package prusalink
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/pstrobl96/prusa_exporter/config"
+)
+
+func TestJobCost(t *testing.T) {
+	resetJobCosts := func() {
+		costJobs, currentJobs = []JobCost{}, map[string]*JobCost{}
+		energies, etaTrackers = map[string]*Energy{}, map[string]*etaTracker{}
+	}
+	resetJobCosts()
+	t.Cleanup(resetJobCosts)
+
+	printer := config.Printers{Address: "127.0.0.1:2", Name: "core", Type: "COREONE"} // scrapes of the collector fail at once
+
+	var cfg config.Config
+	cfg.Exporter.Costs.Enabled = true
+	cfg.Exporter.Costs.Currency = "EUR"
+	cfg.Exporter.Costs.Electricity = 0.3
+	cfg.Exporter.Costs.MachineRate = 2
+	cfg.Exporter.Costs.Materials = map[string]float64{"pla": 25}
+	cfg.Printers = []config.Printers{printer}
+	collector := NewCollector(cfg)
+
+	start := time.Now()
+	snapshot := func(offset time.Duration, state string, job string, progress float64) PrinterSnapshot {
+		status := getStatePrinter(state)
+		return PrinterSnapshot{Printer: printer, Up: true, Time: start.Add(offset), State: status.State.Text, StateCode: getStateFlag(status),
+			Job:   JobSnapshot{Name: job, Progress: &progress, PrintTime: ref(offset.Seconds()), Material: "PLA", Filament: ref(40.0)},
+			Power: ref(120.0)}
+	}
+	update := func(snapshot PrinterSnapshot) {
+		energy, _ := updateEnergy(snapshot)
+		updateJobCost(snapshot, energy)
+	}
+
+	update(snapshot(0, "PRINTING", "benchy.bgcode", 0))
+	update(snapshot(30*time.Minute, "PRINTING", "benchy.bgcode", 0.5)) // gap longer than energyMaxGap, machine time is from the printer
+	update(snapshot(time.Hour-time.Minute, "PRINTING", "benchy.bgcode", 0.99))
+	update(snapshot(time.Hour, "FINISHED", "benchy.bgcode", 1))
+	update(snapshot(time.Hour+time.Minute, "PRINTING", "cube.bgcode", 0))
+	update(snapshot(time.Hour+2*time.Minute, "STOPPED", "", 0.25))
+
+	jobs := GetJobCosts()
+	if len(jobs) != 2 || jobs[0].Name != "cube.bgcode" || jobs[1].Name != "benchy.bgcode" {
+		t.Fatalf("got jobs %+v, want cube.bgcode and benchy.bgcode", jobs)
+	}
+
+	// 40 g of PLA, 120 W for one minute and 59 minutes of printing
+	benchy := jobs[1]
+	want := 0.04*25 + 7200.0/3.6e6*0.3 + 59.0/60*2
+	if benchy.FilamentGrams != 40 || benchy.EnergyJoules != 7200 || math.Abs(benchy.Cost-want) > 1e-9 || benchy.Currency != "EUR" {
+		t.Errorf("got %+v, want 40 g, 7200 J and cost %v EUR", benchy, want)
+	}
+
+	// stopped job used filament only until its last known progress
+	if cube := jobs[0]; cube.FilamentGrams != 0 || cube.State != "Operational" {
+		t.Errorf("got %+v, want 0 g of stopped job", cube)
+	}
+
+	if got := testutil.CollectAndCount(collector, "prusa_job_cost"); got != 1 {
+		t.Errorf("got %d series of prusa_job_cost, want 1", got)
+	}
+
+	for path, want := range map[string]int{"/api/jobs/": 2, "/api/jobs/core": 2, "/api/jobs/unknown": 0} {
+		recorder := httptest.NewRecorder()
+		JobsHandler("/api/jobs").ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+
+		var got []JobCost
+		if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != want {
+			t.Errorf("got %d jobs at %s, want %d", len(got), path, want)
+		}
+	}
+}
+
+func TestOctoPrintJob(t *testing.T) {
+	resetJobCosts := func() {
+		costJobs, currentJobs = []JobCost{}, map[string]*JobCost{}
+		energies, etaTrackers = map[string]*Energy{}, map[string]*etaTracker{}
+	}
+	resetJobCosts()
+	t.Cleanup(resetJobCosts)
+
+	server := newRecordedPrinter("OCTOPRINT")
+	t.Cleanup(server.Close)
+	address, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	printer := config.Printers{Address: address.Host, Name: "octoprint", Type: "OCTOPRINT"}
+
+	var cfg config.Config
+	cfg.Exporter.Costs.Enabled = true
+	cfg.Printers = []config.Printers{printer}
+	NewCollector(cfg)
+
+	// OctoPrint reports printing printer as operational too
+	snapshot, err := octoPrintSource{printer: printer}.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isPrinting(snapshot) {
+		t.Fatalf("got state %v of printing OctoPrint, want %v", snapshot.StateCode, 4)
+	}
+	if eta := updateETA(snapshot); eta == nil {
+		t.Error("no ETA of OctoPrint job")
+	}
+
+	energy, _ := updateEnergy(snapshot)
+	updateJobCost(snapshot, energy)
+
+	idle := PrinterSnapshot{Printer: printer, Up: true, Time: snapshot.Time.Add(time.Minute), State: "Operational", StateCode: 1}
+	energy, _ = updateEnergy(idle)
+	updateJobCost(idle, energy)
+
+	if jobs := GetJobCosts(); len(jobs) != 1 || jobs[0].Name != "calibration_cube.gcode" {
+		t.Errorf("got jobs %+v, want calibration_cube.gcode", jobs)
+	}
+}
+
+func TestParseFilamentUsed(t *testing.T) {
+	tests := []struct {
+		value any
+		want  float64
+		ok    bool
+	}{
+		{12.5, 12.5, true},
+		{"10.00, 2.50, 0.00", 12.5, true},
+		{"n/a", 0, false},
+		{nil, 0, false},
+	}
+
+	for _, test := range tests {
+		if got, ok := parseFilamentUsed(test.value); got != test.want || ok != test.ok {
+			t.Errorf("got %v, %v for %v, want %v, %v", got, ok, test.value, test.want, test.ok)
+		}
+	}
+}
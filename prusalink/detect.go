@@ -0,0 +1,251 @@
+package prusalink
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/rs/zerolog/log"
+)
+
+// Detection is a result of the printer model detection
+type Detection struct {
+	Type   string    // detected printer type - e.g. "MK4", "MK35", "COREONE"
+	Source string    // signal that decided the type - config, hostname, info, profile, job or syslog
+	Final  bool      // false when only family of the printer is known and the type can be refined later
+	Time   time.Time // when the detection was done
+}
+
+// SyslogHints are hardware hints about the printer collected from syslog metrics
+type SyslogHints struct {
+	BuddyBom      string // value of buddy_bom metric
+	BuddyRevision string // value of buddy_revision metric
+	Loadcell      bool   // printer sends loadcell metrics - only printers with Nextruder have loadcell
+	Dwarfs        bool   // printer sends dwarf metrics - only XL has dwarf boards in toolheads
+}
+
+var (
+	// printerFamilies contains printer types that can not be told apart by hostname, they share firmware and default hostname.
+	// MINI and MINI+ are not listed as no signal tells them apart, MINI+ has to be set by type in configuration.
+	printerFamilies = map[string][]string{
+		"MK4": {"MK4", "MK35", "MK39", "COREONE"},
+	}
+
+	// slicerModels maps printer_model from the metadata of sliced file to printer type
+	slicerModels = map[string]string{
+		"MINI":      "MINI",
+		"MINIIS":    "MINI",
+		"MK4":       "MK4",
+		"MK4IS":     "MK4",
+		"MK4S":      "MK4",
+		"MK3.5":     "MK35",
+		"MK3.5S":    "MK35",
+		"MK3.9":     "MK39",
+		"MK3.9S":    "MK39",
+		"COREONE":   "COREONE",
+		"XL":        "XL",
+		"XLIS":      "XL",
+		"XL2IS":     "XL",
+		"XL3IS":     "XL",
+		"XL4IS":     "XL",
+		"XL5IS":     "XL",
+		"iX":        "IX",
+		"MK3S":      "I3MK3S",
+		"MK3SMMU3":  "I3MK3S",
+		"MK3SMMU2S": "I3MK3S",
+		"MK3":       "I3MK3",
+		"MK3MMU2":   "I3MK3",
+		"MK2.5S":    "I3MK25S",
+		"MK2.5":     "I3MK25",
+		"SL1":       "SL1",
+		"SL1S":      "SL1S",
+	}
+
+	// detectInterval is how often printers with unfinished detection are detected again
+	detectInterval = time.Minute
+
+	detectMutex sync.Mutex
+
+	detections  = map[string]Detection{}   // printer address -> detection
+	syslogHints = map[string]SyslogHints{} // ip address -> hints
+	learnedBoms = map[string]string{}      // buddy_bom/buddy_revision -> printer type of conclusively detected printers
+)
+
+// SetSyslogHints stores hardware hints of the printer with the given ip address, detection of the printer is refreshed with next scrape
+func SetSyslogHints(ip string, hints SyslogHints) {
+	detectMutex.Lock()
+	defer detectMutex.Unlock()
+
+	if syslogHints[ip] == hints {
+		return
+	}
+
+	syslogHints[ip] = hints
+
+	for address, detection := range detections {
+		if !detection.Final && addressHost(address) == ip {
+			delete(detections, address)
+		}
+	}
+}
+
+// GetDetection returns the detected printer type of the given printer. Type from the configuration always wins,
+// otherwise the result is cached and detection is repeated only when it was not conclusive.
+func GetDetection(ctx context.Context, printer config.Printers) (Detection, error) {
+	if printer.Type != "" {
+		return Detection{Type: printer.Type, Source: "config", Final: true, Time: time.Now()}, nil
+	}
+
+	detectMutex.Lock()
+	cached, ok := detections[printer.Address]
+	detectMutex.Unlock()
+
+	if ok && (cached.Final || time.Since(cached.Time) < detectInterval) {
+		return cached, nil
+	}
+
+	detection, err := detectPrinter(ctx, printer)
+	if err != nil {
+		return detection, err
+	}
+
+	detectMutex.Lock()
+	detections[printer.Address] = detection
+	detectMutex.Unlock()
+
+	if !ok || cached.Type != detection.Type {
+		log.Info().Msg(detection.Type + " detected for " + printer.Address + " (" + printer.Name + ") by " + detection.Source)
+	}
+
+	return detection, nil
+}
+
+// detectPrinter combines all available signals into the printer type
+func detectPrinter(ctx context.Context, printer config.Printers) (Detection, error) {
+	detection := Detection{Type: "unknown", Time: time.Now()}
+
+	version, err := GetVersion(ctx, printer)
+	if err != nil {
+		return detection, err
+	}
+
+	if printerType := printerTypes[version.Original]; printerType != "" {
+		detection.Type, detection.Source = printerType, "hostname"
+	} else if printerType := printerTypes[version.Hostname]; printerType != "" {
+		detection.Type, detection.Source = printerType, "hostname"
+	} else if info, err := GetInfo(ctx, printer); err == nil && printerTypes[info.Hostname] != "" {
+		detection.Type, detection.Source = printerTypes[info.Hostname], "info"
+	} else if strings.HasPrefix(version.Text, "Prusa SLA") || isSLAProfile(ctx, printer) {
+		// hostname of SL printers can be changed, firmware and profile still tell it is a resin printer
+		detection.Type, detection.Source = "SL1", "profile"
+		if strings.Contains(strings.ToLower(version.Hostname), "sl1s") {
+			detection.Type = "SL1S"
+		}
+	} else if hints, hinted := getSyslogHints(printer); hinted && hints.Dwarfs {
+		detection.Type, detection.Source = "XL", "syslog"
+	} else if hinted && hints.Loadcell {
+		detection.Type, detection.Source = "MK4", "syslog"
+	} else if version.Original != "" {
+		detection.Type, detection.Source = version.Original, "hostname"
+	} else if version.Hostname != "" {
+		detection.Type, detection.Source = version.Hostname, "hostname"
+	}
+
+	family, ambiguous := printerFamilies[detection.Type]
+	if !ambiguous {
+		detection.Final = detection.Type != "unknown"
+		return detection, nil
+	}
+
+	hints, hinted := getSyslogHints(printer)
+
+	// printer_model of the printed file is the strongest signal as files are sliced for exact model
+	if job, err := GetJobV1(ctx, printer); err == nil {
+		if sliced := slicerModels[job.File.Meta.PrinterModel]; contains(family, sliced) {
+			detection.Type, detection.Source, detection.Final = sliced, "job", true
+			if hinted {
+				learnBom(hints, sliced)
+			}
+			return detection, nil
+		}
+	}
+
+	if !hinted {
+		return detection, nil
+	}
+
+	// printers with the same board and bom as conclusively detected printer are likely the same model, it is only a hint
+	// as different models share the bom - e.g. MK4S and CORE One, so detection is repeated and printed file can override it
+	detectMutex.Lock()
+	learned := learnedBoms[hints.BuddyBom+"/"+hints.BuddyRevision]
+	detectMutex.Unlock()
+
+	if contains(family, learned) {
+		detection.Type, detection.Source = learned, "syslog"
+		return detection, nil
+	}
+
+	// MK3.5 is the only printer of MK4 family without loadcell
+	if detection.Type == "MK4" && hints.BuddyBom != "" && !hints.Loadcell {
+		detection.Type, detection.Source = "MK35", "syslog"
+	}
+
+	return detection, nil
+}
+
+// isSLAProfile checks if the printer profile belongs to resin printer
+func isSLAProfile(ctx context.Context, printer config.Printers) bool {
+	profiles, err := GetPrinterProfiles(ctx, printer)
+	if err != nil {
+		return false
+	}
+
+	for _, profile := range profiles.Profiles {
+		if strings.Contains(profile.Model, "SLA") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getSyslogHints returns syslog hints of the printer if it sends syslog metrics
+func getSyslogHints(printer config.Printers) (SyslogHints, bool) {
+	detectMutex.Lock()
+	defer detectMutex.Unlock()
+
+	hints, ok := syslogHints[addressHost(printer.Address)]
+	return hints, ok
+}
+
+// learnBom remembers printer type for bom and revision of the board
+func learnBom(hints SyslogHints, printerType string) {
+	if hints.BuddyBom == "" {
+		return
+	}
+
+	detectMutex.Lock()
+	learnedBoms[hints.BuddyBom+"/"+hints.BuddyRevision] = printerType
+	detectMutex.Unlock()
+}
+
+// addressHost returns address without port
+func addressHost(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
+
+// contains checks if the printer type is part of the family
+func contains(family []string, printerType string) bool {
+	for _, member := range family {
+		if member == printerType {
+			return true
+		}
+	}
+	return false
+}
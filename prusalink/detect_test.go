@@ -0,0 +1,138 @@
+package prusalink
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/pstrobl96/prusa_exporter/simulator"
+)
+
+// resetDetection forgets all cached detections, syslog hints and learned boms
+func resetDetection() {
+	detectMutex.Lock()
+	detections = map[string]Detection{}
+	syslogHints = map[string]SyslogHints{}
+	learnedBoms = map[string]string{}
+	detectMutex.Unlock()
+}
+
+// newSimulatedPrinter returns configuration of simulated printer, printing is true when the printer is in the middle of a job
+func newSimulatedPrinter(t *testing.T, model string, printing bool) config.Printers {
+	t.Helper()
+
+	now := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	printer, err := simulator.New(simulator.Options{Model: model, Now: func() time.Time { return now }})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if printing {
+		now = now.Add(5 * time.Minute) // default script prints after 30 seconds of idle
+	}
+
+	server := httptest.NewServer(printer)
+	t.Cleanup(server.Close)
+
+	address, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return config.Printers{Address: address.Host, Name: model}
+}
+
+func TestDetection(t *testing.T) {
+	configuration.Exporter.ScrapeTimeout = 1000
+
+	tests := []struct {
+		name     string
+		model    string
+		printing bool
+		hints    *SyslogHints
+		override string
+		want     Detection
+	}{
+		{name: "hostname", model: "XL", want: Detection{Type: "XL", Source: "hostname", Final: true}},
+		{name: "core one hostname", model: "COREONE", want: Detection{Type: "COREONE", Source: "hostname", Final: true}},
+		{name: "einsy original", model: "I3MK3S", want: Detection{Type: "I3MK3S", Source: "hostname", Final: true}},
+		{name: "shared hostname", model: "MK39", want: Detection{Type: "MK4", Source: "hostname"}},
+		{name: "printed file", model: "MK35", printing: true, want: Detection{Type: "MK35", Source: "job", Final: true}},
+		{name: "printed file of other family", model: "MK39", printing: true, want: Detection{Type: "MK39", Source: "job", Final: true}},
+		{name: "no loadcell", model: "MK35", hints: &SyslogHints{BuddyBom: "30", BuddyRevision: "14"}, want: Detection{Type: "MK35", Source: "syslog"}},
+		{name: "loadcell", model: "MK4", hints: &SyslogHints{BuddyBom: "34", BuddyRevision: "37", Loadcell: true}, want: Detection{Type: "MK4", Source: "hostname"}},
+		{name: "override", model: "MK35", override: "MK39", want: Detection{Type: "MK39", Source: "config", Final: true}},
+		{name: "mini plus override", model: "MINIPLUS", override: "MINIPLUS", want: Detection{Type: "MINIPLUS", Source: "config", Final: true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resetDetection()
+
+			printer := newSimulatedPrinter(t, test.model, test.printing)
+			printer.Type = test.override
+			if test.hints != nil {
+				SetSyslogHints(addressHost(printer.Address), *test.hints)
+			}
+
+			got, err := GetDetection(context.Background(), printer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got.Time = time.Time{}
+			if got != test.want {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDetectionLearnsBom(t *testing.T) {
+	configuration.Exporter.ScrapeTimeout = 1000
+	resetDetection()
+
+	// printers share the address but not the port, so both get the same hints
+	hints := SyslogHints{BuddyBom: "14", BuddyRevision: "27", Loadcell: true}
+
+	printing := newSimulatedPrinter(t, "MK39", true)
+	SetSyslogHints(addressHost(printing.Address), hints)
+	if detection, err := GetDetection(context.Background(), printing); err != nil || detection.Type != "MK39" {
+		t.Fatalf("printing printer detected as %+v, %v", detection, err)
+	}
+
+	idle := newSimulatedPrinter(t, "MK39", false)
+	detection, err := GetDetection(context.Background(), idle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if detection.Type != "MK39" || detection.Source != "syslog" || detection.Final {
+		t.Errorf("idle printer detected as %+v, want not final MK39 from syslog", detection)
+	}
+}
+
+func TestDetectionCache(t *testing.T) {
+	configuration.Exporter.ScrapeTimeout = 1000
+	resetDetection()
+
+	printer := newSimulatedPrinter(t, "MK35", false)
+	if detection, err := GetDetection(context.Background(), printer); err != nil || detection.Type != "MK4" || detection.Final {
+		t.Fatalf("detected as %+v, %v", detection, err)
+	}
+
+	// new hints invalidate the cached detection which is not final
+	SetSyslogHints(addressHost(printer.Address), SyslogHints{BuddyBom: "30", BuddyRevision: "14"})
+
+	detection, err := GetDetection(context.Background(), printer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detection.Type != "MK35" {
+		t.Errorf("detected as %+v after syslog hints, want MK35", detection)
+	}
+}
@@ -0,0 +1,176 @@
+package prusalink
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// progressSample is progress of the job at one moment while the printer was printing
+type progressSample struct {
+	time     time.Time
+	progress float64
+}
+
+// etaTracker follows progress of the job printed by one printer
+type etaTracker struct {
+	name      string
+	path      string
+	material  string
+	estimate  float64 // slicer estimate of the whole print time, 0 when unknown
+	printTime float64 // the last print time reported by the printer
+	samples   []progressSample
+}
+
+// estimateKey is printer model and material of finished jobs with the same estimate error
+type estimateKey struct {
+	model    string
+	material string
+}
+
+var (
+	// etaWindow is time of printing from which progress rate of the job is computed
+	etaWindow = 15 * time.Minute
+
+	// etaMinSpan is the shortest time of printing that gives usable progress rate
+	etaMinSpan = time.Minute
+
+	// estimateHistory is number of finished jobs per printer model and material used for the estimate error
+	estimateHistory = 20
+
+	etaMutex       sync.Mutex
+	etaTrackers    = map[string]*etaTracker{}    // printer address -> job that is printed
+	estimateErrors = map[estimateKey][]float64{} // relative error of slicer estimates of finished jobs
+)
+
+// updateETA follows progress of the job of the printer and returns estimated time when the job ends, nil when it is not known.
+// Estimate error of the slicer is recorded when the job finishes.
+func updateETA(snapshot PrinterSnapshot) *time.Time {
+	if !snapshot.Up {
+		return nil
+	}
+
+	etaMutex.Lock()
+	defer etaMutex.Unlock()
+
+	s := snapshot.Printer
+	job := getActiveJob(snapshot)
+	tracker := etaTrackers[s.Address]
+
+	if tracker != nil && (tracker.name != job.Name || tracker.path != job.Path) {
+		if snapshot.Job.Name == tracker.name && snapshot.Job.Path == tracker.path && snapshot.Job.PrintTime != nil {
+			tracker.printTime = *snapshot.Job.PrintTime // finished job is still reported with its final print time
+		}
+		if isFinished(snapshot) && tracker.estimate > 0 && tracker.printTime > 0 {
+			key := estimateKey{model: s.Type, material: tracker.material}
+			ratios := append(estimateErrors[key], (tracker.printTime-tracker.estimate)/tracker.estimate)
+			if len(ratios) > estimateHistory {
+				ratios = ratios[len(ratios)-estimateHistory:]
+			}
+			estimateErrors[key] = ratios
+		}
+		delete(etaTrackers, s.Address)
+		tracker = nil
+	}
+
+	if job.Name == "" && job.Path == "" {
+		return nil
+	}
+
+	if tracker == nil {
+		tracker = &etaTracker{name: job.Name, path: job.Path}
+		etaTrackers[s.Address] = tracker
+	}
+
+	tracker.material = job.Material
+	if tracker.material == "" && snapshot.Material != nil && snapshot.Material.Loaded {
+		tracker.material = snapshot.Material.Name
+	}
+	if job.Estimate != nil {
+		tracker.estimate = *job.Estimate
+	}
+	if job.PrintTime != nil {
+		tracker.printTime = *job.PrintTime
+	}
+
+	if job.Progress == nil {
+		return nil
+	}
+	progress := *job.Progress
+
+	// paused printer does not make progress, so only samples of printing are used for progress rate
+	if isPrinting(snapshot) {
+		samples := []progressSample{}
+		for _, sample := range tracker.samples {
+			if snapshot.Time.Sub(sample.time) <= etaWindow {
+				samples = append(samples, sample)
+			}
+		}
+		tracker.samples = append(samples, progressSample{time: snapshot.Time, progress: progress})
+	}
+
+	remaining, ok := getRemaining(tracker.samples, progress, job)
+	if !ok {
+		return nil
+	}
+	eta := snapshot.Time.Add(time.Duration(remaining * float64(time.Second)))
+	return &eta
+}
+
+// getRemaining returns time in seconds that remains to the end of the job. Remaining time from observed progress rate
+// and time remaining from the firmware are weighted by progress - firmware estimate is trusted at the start of the job
+// and observed rate near its end. Firmware estimate is not used when the firmware itself reports it as inaccurate.
+func getRemaining(samples []progressSample, progress float64, job JobSnapshot) (float64, bool) {
+	observed, observedOk := 0.0, false
+	if len(samples) > 1 {
+		first, last := samples[0], samples[len(samples)-1]
+		span := last.time.Sub(first.time)
+		if span >= etaMinSpan && last.progress > first.progress {
+			rate := (last.progress - first.progress) / span.Seconds()
+			observed, observedOk = (1-progress)/rate, true
+		}
+	}
+
+	firmware, firmwareOk := 0.0, false
+	if job.TimeRemaining != nil && (job.InaccurateEstimates == nil || !*job.InaccurateEstimates) {
+		firmware, firmwareOk = *job.TimeRemaining, true
+	}
+
+	switch {
+	case observedOk && firmwareOk:
+		return progress*observed + (1-progress)*firmware, true
+	case observedOk:
+		return observed, true
+	case firmwareOk:
+		return firmware, true
+	}
+	return 0, false
+}
+
+// estimateError is mean relative error of slicer estimates of finished jobs of the printer model with the material
+type estimateError struct {
+	estimateKey
+	ratio float64
+}
+
+// getEstimateErrors returns estimate errors of all printer models and materials sorted by model and material
+func getEstimateErrors() []estimateError {
+	etaMutex.Lock()
+	defer etaMutex.Unlock()
+
+	estimates := []estimateError{}
+	for key, ratios := range estimateErrors {
+		sum := 0.0
+		for _, ratio := range ratios {
+			sum += ratio
+		}
+		estimates = append(estimates, estimateError{estimateKey: key, ratio: sum / float64(len(ratios))})
+	}
+	sort.Slice(estimates, func(i, j int) bool {
+		if estimates[i].model != estimates[j].model {
+			return estimates[i].model < estimates[j].model
+		}
+		return estimates[i].material < estimates[j].material
+	})
+	return estimates
+}
@@ -0,0 +1,56 @@
+package prusalink
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+)
+
+func TestETA(t *testing.T) {
+	printer := config.Printers{Address: "127.0.0.1:3", Name: "mini", Type: "MINI"}
+	t.Cleanup(func() { estimateErrors = map[estimateKey][]float64{} }) // estimate errors are returned with metrics of all printers
+
+	start := time.Now()
+	snapshot := func(offset time.Duration, state string, progress float64, remaining float64, inaccurate bool) PrinterSnapshot {
+		status := getStatePrinter(state)
+		return PrinterSnapshot{Printer: printer, Up: true, Time: start.Add(offset), State: status.State.Text, StateCode: getStateFlag(status),
+			Job: JobSnapshot{Name: "benchy.bgcode", Progress: &progress, PrintTime: ref(offset.Seconds()), TimeRemaining: &remaining,
+				Estimate: ref(3000.0), Material: "PLA", InaccurateEstimates: &inaccurate}}
+	}
+
+	// firmware alone is used until progress rate is observed
+	if eta := updateETA(snapshot(0, "PRINTING", 0, 3000, false)); eta == nil || !eta.Equal(start.Add(3000*time.Second)) {
+		t.Errorf("got ETA %v, want time remaining of the firmware", eta)
+	}
+
+	// 20 % in 10 minutes is 40 minutes remaining, firmware says 30 minutes, observed rate has weight of progress
+	eta := updateETA(snapshot(10*time.Minute, "PRINTING", 0.2, 1800, false))
+	if want := start.Add(10*time.Minute + time.Duration(0.2*2400+0.8*1800)*time.Second); eta == nil || !eta.Equal(want) {
+		t.Errorf("got ETA %v, want %v", eta, want)
+	}
+
+	// inaccurate firmware estimate is not used, paused printer does not change progress rate
+	updateETA(snapshot(15*time.Minute, "PAUSED", 0.2, 1800, true))
+	eta = updateETA(snapshot(20*time.Minute, "PAUSED", 0.2, 1800, true))
+	if want := start.Add(20*time.Minute + 2400*time.Second); eta == nil || !eta.Equal(want) {
+		t.Errorf("got ETA %v of paused job, want %v", eta, want)
+	}
+
+	finished := snapshot(time.Hour, "FINISHED", 1, 0, false)
+	if eta := updateETA(finished); eta != nil {
+		t.Errorf("got ETA %v of finished job, want none", eta)
+	}
+
+	// finished job took 3600 s instead of 3000 s estimated by slicer
+	for _, estimate := range getEstimateErrors() {
+		if estimate.model == "MINI" && estimate.material == "PLA" {
+			if math.Abs(estimate.ratio-0.2) > 1e-9 {
+				t.Errorf("got estimate error %v, want 0.2", estimate.ratio)
+			}
+			return
+		}
+	}
+	t.Error("no estimate error of MINI with PLA")
+}
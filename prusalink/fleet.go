@@ -0,0 +1,232 @@
+package prusalink
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/pstrobl96/prusa_exporter/config"
+)
+
+// fleetSegment is time when the printer was observed in the same state without gaps
+type fleetSegment struct {
+	from     time.Time
+	to       time.Time
+	printing bool
+	offline  bool // time when the printer was down is not counted in utilisation
+}
+
+// fleetPrinter is the last state and state history of one printer
+type fleetPrinter struct {
+	group    string
+	state    string
+	queued   *float64
+	seen     time.Time
+	segments []fleetSegment // the oldest is the first, segments older than the longest window are dropped
+}
+
+// fleetWindow is rolling window of utilisation
+type fleetWindow struct {
+	name     string
+	duration time.Duration
+}
+
+var (
+	// fleetStates are states of printers counted by prusa_fleet_printers, all of them are returned for every group
+	fleetStates = []string{"printing", "paused", "idle", "finished", "busy", "error", "offline"}
+
+	// fleetWindows are windows of prusa_fleet_utilisation_ratio, the longest one is the last
+	fleetWindows = []fleetWindow{{"1h", time.Hour}, {"24h", 24 * time.Hour}, {"7d", 7 * 24 * time.Hour}}
+
+	// fleetMaxGap is the longest time between two snapshots of the printer that is counted as observed
+	fleetMaxGap = 5 * time.Minute
+
+	fleetMutex    sync.Mutex
+	fleetPrinters = map[string]*fleetPrinter{} // printer address -> state of the printer
+)
+
+// FleetCollector is a collector of aggregate metrics of all printers by group, it uses snapshots of printers collected by Collector
+type FleetCollector struct {
+	printers    *prometheus.Desc
+	utilisation *prometheus.Desc
+	queued      *prometheus.Desc
+}
+
+// NewFleetCollector returns a new FleetCollector
+func NewFleetCollector() *FleetCollector {
+	return &FleetCollector{
+		printers:    prometheus.NewDesc("prusa_fleet_printers", "Number of printers of the group in the state", []string{"group", "state"}, nil),
+		utilisation: prometheus.NewDesc("prusa_fleet_utilisation_ratio", "Ratio of time the printers of the group were printing in the window", []string{"group", "window"}, nil),
+		queued:      prometheus.NewDesc("prusa_fleet_queued_jobs", "Number of jobs waiting in queues of printers of the group", []string{"group"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (collector *FleetCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.printers
+	ch <- collector.utilisation
+	ch <- collector.queued
+}
+
+// Collect implements prometheus.Collector
+func (collector *FleetCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, group := range getFleet(now()) {
+		for _, state := range fleetStates {
+			ch <- prometheus.MustNewConstMetric(collector.printers, prometheus.GaugeValue, group.states[state], group.name, state)
+		}
+		for _, window := range fleetWindows {
+			if ratio, ok := group.utilisation[window.name]; ok {
+				ch <- prometheus.MustNewConstMetric(collector.utilisation, prometheus.GaugeValue, ratio, group.name, window.name)
+			}
+		}
+		if group.queued != nil {
+			ch <- prometheus.MustNewConstMetric(collector.queued, prometheus.GaugeValue, *group.queued, group.name)
+		}
+	}
+}
+
+// getFleetState returns state of the printer counted by prusa_fleet_printers
+func getFleetState(snapshot PrinterSnapshot) string {
+	switch {
+	case !snapshot.Up:
+		return "offline"
+	case isFinished(snapshot):
+		return "finished"
+	case isPrinting(snapshot):
+		return "printing"
+	case isPaused(snapshot):
+		return "paused"
+	case isBusy(snapshot):
+		return "busy"
+	case isFailed(snapshot):
+		return "error"
+	}
+	return "idle"
+}
+
+// recordFleet adds the snapshot to state history of the printer
+func recordFleet(snapshot PrinterSnapshot) {
+	fleetMutex.Lock()
+	defer fleetMutex.Unlock()
+
+	printer := fleetPrinters[snapshot.Printer.Address]
+	if printer == nil {
+		printer = &fleetPrinter{}
+		fleetPrinters[snapshot.Printer.Address] = printer
+	}
+
+	printer.group = snapshot.Printer.Group
+	printer.state = getFleetState(snapshot)
+	printer.queued = snapshot.QueuedJobs
+	printer.seen = snapshot.Time
+
+	printing, offline := printer.state == "printing", printer.state == "offline"
+	if last := len(printer.segments) - 1; last >= 0 && snapshot.Time.Sub(printer.segments[last].to) <= fleetMaxGap {
+		printer.segments[last].to = snapshot.Time // the previous state lasted until this snapshot
+		if printer.segments[last].printing == printing && printer.segments[last].offline == offline {
+			return
+		}
+	}
+	printer.segments = append(printer.segments, fleetSegment{from: snapshot.Time, to: snapshot.Time, printing: printing, offline: offline})
+
+	oldest := snapshot.Time.Add(-fleetWindows[len(fleetWindows)-1].duration)
+	for len(printer.segments) > 0 && printer.segments[0].to.Before(oldest) {
+		printer.segments = printer.segments[1:]
+	}
+}
+
+// pruneFleet drops state of printers that are not scraped anymore. Printers of Prusa Connect are not configured,
+// they are dropped when they were not seen for the longest window.
+func pruneFleet(printers []config.Printers, at time.Time) {
+	fleetMutex.Lock()
+	defer fleetMutex.Unlock()
+
+	scraped := map[string]bool{}
+	for _, printer := range printers {
+		scraped[printer.Address] = true
+	}
+
+	oldest := at.Add(-fleetWindows[len(fleetWindows)-1].duration)
+	for address, printer := range fleetPrinters {
+		if strings.HasPrefix(address, "connect/") {
+			if printer.seen.Before(oldest) {
+				delete(fleetPrinters, address)
+			}
+		} else if !scraped[address] {
+			delete(fleetPrinters, address)
+		}
+	}
+}
+
+// fleetGroup is aggregate state of printers of one group
+type fleetGroup struct {
+	name        string
+	states      map[string]float64 // state -> number of printers
+	utilisation map[string]float64 // window -> ratio of printing time to time when printers were up, window without it is missing
+	queued      *float64           // nil when no printer of the group has a queue
+}
+
+// getFleet returns aggregate state of groups at the time sorted by name. Printers not seen for fleetMaxGap are not counted,
+// e.g. printers removed from Prusa Connect.
+func getFleet(at time.Time) []fleetGroup {
+	fleetMutex.Lock()
+	defer fleetMutex.Unlock()
+
+	groups := map[string]*fleetGroup{}
+	printing := map[string]map[string]time.Duration{} // group -> window -> printing time
+	observed := map[string]map[string]time.Duration{} // group -> window -> observed time
+
+	for _, printer := range fleetPrinters {
+		group := groups[printer.group]
+		if group == nil {
+			group = &fleetGroup{name: printer.group, states: map[string]float64{}, utilisation: map[string]float64{}}
+			groups[printer.group] = group
+			printing[printer.group] = map[string]time.Duration{}
+			observed[printer.group] = map[string]time.Duration{}
+		}
+
+		if at.Sub(printer.seen) <= fleetMaxGap {
+			group.states[printer.state]++
+			if printer.queued != nil {
+				if group.queued == nil {
+					group.queued = ref(0.0)
+				}
+				*group.queued += *printer.queued
+			}
+		}
+
+		for _, window := range fleetWindows {
+			start := at.Add(-window.duration)
+			for _, segment := range printer.segments {
+				from, to := segment.from, segment.to
+				if from.Before(start) {
+					from = start
+				}
+				if to.After(at) {
+					to = at
+				}
+				if segment.offline || !to.After(from) {
+					continue
+				}
+				observed[printer.group][window.name] += to.Sub(from)
+				if segment.printing {
+					printing[printer.group][window.name] += to.Sub(from)
+				}
+			}
+		}
+	}
+
+	fleet := []fleetGroup{}
+	for name, group := range groups {
+		for window, duration := range observed[name] {
+			group.utilisation[window] = printing[name][window].Seconds() / duration.Seconds()
+		}
+		fleet = append(fleet, *group)
+	}
+	sort.Slice(fleet, func(i, j int) bool {
+		return fleet[i].name < fleet[j].name
+	})
+	return fleet
+}
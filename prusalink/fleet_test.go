@@ -0,0 +1,88 @@
+package prusalink
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/pstrobl96/prusa_exporter/config"
+)
+
+func TestFleet(t *testing.T) {
+	fixNow(t)
+	t.Cleanup(func() { fleetPrinters = map[string]*fleetPrinter{} })
+
+	at := now()
+	record := func(address string, group string, offset time.Duration, state string, queued *float64) {
+		snapshot := PrinterSnapshot{Printer: config.Printers{Address: address, Group: group}, Time: at.Add(offset), QueuedJobs: queued}
+		if state != "" {
+			status := getStatePrinter(state)
+			snapshot.Up, snapshot.State, snapshot.StateCode = true, status.State.Text, getStateFlag(status)
+		}
+		recordFleet(snapshot)
+	}
+
+	// printer of the farm printed for the last 30 minutes of the hour, scrapes are every minute
+	for minute := -60; minute <= 0; minute++ {
+		state := "IDLE"
+		if minute > -30 {
+			state = "PRINTING"
+		}
+		record("mk4", "farm", time.Duration(minute)*time.Minute, state, ref(2.0))
+		record("xl", "farm", time.Duration(minute)*time.Minute, "", ref(1.0))
+	}
+	record("mini", "office", -2*time.Hour, "PRINTING", nil) // not seen for longer than fleetMaxGap
+
+	fleet := getFleet(at)
+	if len(fleet) != 2 || fleet[0].name != "farm" || fleet[1].name != "office" {
+		t.Fatalf("got groups %+v, want farm and office", fleet)
+	}
+
+	farm := fleet[0]
+	if farm.states["printing"] != 1 || farm.states["offline"] != 1 || *farm.queued != 3 {
+		t.Errorf("got states %v and %v queued jobs, want 1 printing, 1 offline and 3 queued", farm.states, *farm.queued)
+	}
+
+	// 29 minutes of printing out of 60 minutes when mk4 was up, xl was offline
+	if ratio := farm.utilisation["1h"]; math.Abs(ratio-29.0/60) > 1e-9 {
+		t.Errorf("got utilisation %v in 1h, want %v", ratio, 29.0/60)
+	}
+
+	office := fleet[1]
+	if office.states["printing"] != 0 || office.queued != nil || len(office.utilisation) != 0 {
+		t.Errorf("got office %+v, want no printers counted and no utilisation in windows", office)
+	}
+
+	for name, want := range map[string]int{
+		"prusa_fleet_printers":          2 * len(fleetStates),
+		"prusa_fleet_utilisation_ratio": len(fleetWindows),
+		"prusa_fleet_queued_jobs":       1,
+	} {
+		if got := testutil.CollectAndCount(NewFleetCollector(), name); got != want {
+			t.Errorf("got %d series of %s, want %d", got, name, want)
+		}
+	}
+}
+
+func TestPruneFleet(t *testing.T) {
+	fixNow(t)
+	t.Cleanup(func() { fleetPrinters = map[string]*fleetPrinter{} })
+
+	at := now()
+	for _, address := range []string{"mk4", "xl", "connect/recent", "connect/old"} {
+		seen := at
+		if address == "connect/old" {
+			seen = at.Add(-8 * 24 * time.Hour)
+		}
+		recordFleet(PrinterSnapshot{Printer: config.Printers{Address: address, Group: "farm"}, Time: seen})
+	}
+
+	pruneFleet([]config.Printers{{Address: "mk4"}}, at)
+
+	for address, want := range map[string]bool{"mk4": true, "xl": false, "connect/recent": true, "connect/old": false} {
+		if _, ok := fleetPrinters[address]; ok != want {
+			t.Errorf("printer %s is kept %v, want %v", address, ok, want)
+		}
+	}
+}
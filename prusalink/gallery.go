@@ -0,0 +1,283 @@
+package prusalink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/rs/zerolog/log"
+)
+
+// Thumbnail is a cached thumbnail of the printed file
+type Thumbnail struct {
+	File        string    // path of the printed file the thumbnail belongs to
+	ContentType string    // content type returned by the printer - e.g. image/png
+	Image       []byte    // thumbnail itself
+	Time        time.Time // when the thumbnail was downloaded
+}
+
+// JobMetadata is a metadata of the printed file served by the gallery endpoint
+type JobMetadata struct {
+	Printer            string            `json:"printer"`
+	Address            string            `json:"printer_address"`
+	Model              string            `json:"printer_model"`
+	State              string            `json:"state"`
+	Name               string            `json:"file_name,omitempty"`
+	DisplayName        string            `json:"display_name,omitempty"`
+	Path               string            `json:"file_path,omitempty"`
+	Size               float64           `json:"size,omitempty"`
+	Progress           float64           `json:"progress"`
+	TimePrinting       float64           `json:"time_printing"`
+	TimeRemaining      float64           `json:"time_remaining"`
+	EstimatedPrintTime float64           `json:"estimated_print_time,omitempty"`
+	LayerHeight        float64           `json:"layer_height,omitempty"`
+	FilamentType       string            `json:"filament_type,omitempty"`
+	SlicedFor          string            `json:"sliced_for,omitempty"` // printer_model from metadata of the file
+	Thumbnail          string            `json:"thumbnail,omitempty"`  // URL path of the thumbnail on the exporter
+	Labels             map[string]string `json:"labels,omitempty"`
+	thumbnailRef       string            // path of the thumbnail on the printer
+}
+
+var (
+	// errNoJob is returned when the printer does not print anything
+	errNoJob = errors.New("printer does not print anything")
+
+	thumbnailMutex sync.Mutex
+	thumbnails     = map[string]Thumbnail{} // printer address -> thumbnail of the printed file
+)
+
+// GetJobMetadata returns metadata of the file printed by the given printer, file fields are empty when printer does not print
+func GetJobMetadata(ctx context.Context, printer config.Printers) (JobMetadata, error) {
+	metadata := JobMetadata{Printer: GetPrinterID(printer), Address: printer.Address, Model: printer.Type,
+		Labels: configuration.GetPrinterLabels(printer)}
+
+	if metadata.Model == "" {
+		printerType, err := GetPrinterType(ctx, printer)
+		if err != nil {
+			return metadata, err
+		}
+		metadata.Model = printerType
+	}
+
+	job, err := GetJob(ctx, printer)
+	if err != nil {
+		return metadata, err
+	}
+
+	metadata.State = job.State
+	if job.Job.File.Name == "" {
+		return metadata, nil
+	}
+
+	metadata.Name = job.Job.File.Name
+	metadata.DisplayName = job.Job.File.Display
+	metadata.Path = job.Job.File.Path
+	metadata.Size = job.Job.File.Size
+	metadata.Progress = job.Progress.Completion * 100
+	metadata.TimePrinting = job.Progress.PrintTime
+	metadata.TimeRemaining = job.Progress.PrintTimeLeft
+	metadata.EstimatedPrintTime = job.Job.EstimatedPrintTime
+
+	// v1 job endpoint has metadata of the file, SL printers do not have it
+	if printerBoards[metadata.Model] != "sl" {
+		if jobV1, err := GetJobV1(ctx, printer); err == nil && jobV1.File.Name != "" {
+			metadata.State = jobV1.State
+			metadata.DisplayName = jobV1.File.DisplayName
+			metadata.Progress = jobV1.Progress
+			metadata.TimePrinting = jobV1.TimePrinting
+			metadata.TimeRemaining = jobV1.TimeRemaining
+			metadata.EstimatedPrintTime = jobV1.File.Meta.EstimatedPrintTime
+			metadata.LayerHeight = jobV1.File.Meta.LayerHeight
+			metadata.FilamentType = jobV1.File.Meta.FilamentType
+			metadata.SlicedFor = jobV1.File.Meta.PrinterModel
+			if thumbnail, ok := jobV1.File.Refs.Thumbnail.(string); ok {
+				metadata.thumbnailRef = thumbnail
+			}
+		}
+	}
+
+	if metadata.thumbnailRef == "" {
+		metadata.thumbnailRef = findThumbnailRef(ctx, printer, metadata.Path)
+	}
+
+	return metadata, nil
+}
+
+// findThumbnailRef returns thumbnail of the file from /api/files for firmware without v1 job endpoint
+func findThumbnailRef(ctx context.Context, printer config.Printers, path string) string {
+	files, err := GetFiles(ctx, printer)
+	if err != nil {
+		return ""
+	}
+
+	var find func(file File) string
+	find = func(file File) string {
+		if file.Path == path || strings.TrimPrefix(file.Path, "/") == strings.TrimPrefix(path, "/") {
+			return file.Refs.ThumbnailBig
+		}
+		for _, child := range file.Children {
+			if ref := find(child); ref != "" {
+				return ref
+			}
+		}
+		return ""
+	}
+
+	for _, file := range files.Files {
+		if ref := find(file); ref != "" {
+			return ref
+		}
+	}
+
+	return ""
+}
+
+// GetThumbnail returns thumbnail of the file printed by the given printer. Thumbnail is downloaded only once for every printed file.
+func GetThumbnail(ctx context.Context, printer config.Printers) (Thumbnail, error) {
+	metadata, err := GetJobMetadata(ctx, printer)
+	if err != nil {
+		return Thumbnail{}, err
+	}
+
+	if metadata.Name == "" {
+		return Thumbnail{}, errNoJob
+	}
+
+	file := metadata.Path // path of the legacy job endpoint contains name of the file
+
+	thumbnailMutex.Lock()
+	cached, ok := thumbnails[printer.Address]
+	thumbnailMutex.Unlock()
+
+	if ok && cached.File == file {
+		return cached, nil
+	}
+
+	if metadata.thumbnailRef == "" {
+		return Thumbnail{}, errors.New("file " + file + " has no thumbnail")
+	}
+
+	res, err := getPrinterResponse(ctx, metadata.thumbnailRef, printer)
+	if err != nil {
+		return Thumbnail{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return Thumbnail{}, errors.New("thumbnail " + metadata.thumbnailRef + " returned " + res.Status)
+	}
+
+	image, err := io.ReadAll(res.Body)
+	if err != nil {
+		return Thumbnail{}, err
+	}
+
+	thumbnail := Thumbnail{File: file, ContentType: res.Header.Get("Content-Type"), Image: image, Time: time.Now()}
+
+	thumbnailMutex.Lock()
+	thumbnails[printer.Address] = thumbnail
+	thumbnailMutex.Unlock()
+
+	return thumbnail, nil
+}
+
+// GetPrinterID returns identifier of the printer used in gallery paths - name of the printer or address if name is not set
+func GetPrinterID(printer config.Printers) string {
+	if printer.Name != "" {
+		return printer.Name
+	}
+	return printer.Address
+}
+
+// GalleryHandler returns handler serving metadata and thumbnails of printed files at the given prefix - e.g. /gallery.
+// <prefix>/ lists metadata of all printers, <prefix>/<printer> returns metadata of one printer and <prefix>/<printer>/thumbnail its thumbnail.
+func GalleryHandler(prefix string) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	// withThumbnail sets URL of the thumbnail on the exporter when the printed file has a thumbnail
+	withThumbnail := func(metadata JobMetadata) JobMetadata {
+		if metadata.thumbnailRef != "" {
+			metadata.Thumbnail = prefix + "/" + url.PathEscape(metadata.Printer) + "/thumbnail"
+		}
+		return metadata
+	}
+
+	return http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(r.URL.Path, "/")
+
+		if path == "" {
+			gallery := []JobMetadata{}
+			for _, printer := range GetPrinters() {
+				if _, ok := printerSources[printer.Type]; ok {
+					continue // gallery reads metadata of PrusaLink only
+				}
+				metadata, err := GetJobMetadata(r.Context(), printer)
+				if err != nil {
+					log.Error().Msg("Error while getting job metadata at " + printer.Address + " - " + err.Error())
+					metadata.State = "OFFLINE"
+				}
+				gallery = append(gallery, withThumbnail(metadata))
+			}
+			writeJSON(w, gallery)
+			return
+		}
+
+		id, resource, _ := strings.Cut(path, "/")
+
+		var printer *config.Printers
+		printers := GetPrinters()
+		for i := range printers {
+			if _, ok := printerSources[printers[i].Type]; ok {
+				continue
+			}
+			if GetPrinterID(printers[i]) == id || printers[i].Address == id {
+				printer = &printers[i]
+			}
+		}
+
+		if printer == nil {
+			http.Error(w, "unknown printer "+id, http.StatusNotFound)
+			return
+		}
+
+		switch resource {
+		case "", "metadata":
+			metadata, err := GetJobMetadata(r.Context(), *printer)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			writeJSON(w, withThumbnail(metadata))
+		case "thumbnail":
+			thumbnail, err := GetThumbnail(r.Context(), *printer)
+			if err == errNoJob {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			} else if err != nil {
+				log.Error().Msg("Error while getting thumbnail at " + printer.Address + " - " + err.Error())
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			w.Header().Set("Content-Type", thumbnail.ContentType)
+			w.Header().Set("Last-Modified", thumbnail.Time.UTC().Format(http.TimeFormat))
+			w.Write(thumbnail.Image)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// writeJSON writes the value as JSON response
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Error().Msg("Error while writing JSON response - " + err.Error())
+	}
+}
@@ -0,0 +1,90 @@
+package prusalink
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+)
+
+func TestGallery(t *testing.T) {
+	mk4 := newSimulatedPrinter(t, "MK4", true)
+	mk4.Type = "MK4"
+	mk3s := newSimulatedPrinter(t, "I3MK3S", false)
+	mk3s.Type = "I3MK3S"
+	sl1 := newSimulatedPrinter(t, "SL1", true)
+	sl1.Type = "SL1"
+
+	configuration = config.Config{Printers: []config.Printers{mk4, mk3s, sl1}}
+	configuration.Exporter.ScrapeTimeout = 1000
+
+	thumbnailMutex.Lock()
+	thumbnails = map[string]Thumbnail{}
+	thumbnailMutex.Unlock()
+
+	gallery := httptest.NewServer(GalleryHandler("/gallery"))
+	t.Cleanup(gallery.Close)
+
+	get := func(path string) *http.Response {
+		t.Helper()
+		res, err := http.Get(gallery.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { res.Body.Close() })
+		return res
+	}
+
+	var list []JobMetadata
+	if err := json.NewDecoder(get("/gallery/").Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 3 {
+		t.Fatalf("gallery has %d printers, want 3", len(list))
+	}
+
+	if got := list[0]; got.Printer != "MK4" || got.LayerHeight != 0.2 || got.SlicedFor != "MK4IS" || got.Thumbnail != "/gallery/MK4/thumbnail" {
+		t.Errorf("unexpected metadata of printing MK4 %+v", got)
+	}
+
+	if got := list[1]; got.Name != "" || got.Thumbnail != "" {
+		t.Errorf("idle printer has printed file %+v", got)
+	}
+
+	if got := list[2]; got.Name == "" || got.Thumbnail != "" {
+		t.Errorf("unexpected metadata of printing SL1 %+v", got)
+	}
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+	}{
+		{"/gallery/MK4", http.StatusOK, "application/json"},
+		{"/gallery/MK4/thumbnail", http.StatusOK, "image/png"},
+		{"/gallery/MK4/thumbnail", http.StatusOK, "image/png"},
+		{"/gallery/I3MK3S/thumbnail", http.StatusNotFound, ""},
+		{"/gallery/SL1/thumbnail", http.StatusBadGateway, ""},
+		{"/gallery/unknown", http.StatusNotFound, ""},
+		{"/gallery/MK4/unknown", http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		res := get(test.path)
+		if res.StatusCode != test.status {
+			t.Errorf("%s returned %d, want %d", test.path, res.StatusCode, test.status)
+		}
+		if test.contentType != "" && res.Header.Get("Content-Type") != test.contentType {
+			t.Errorf("%s returned %s, want %s", test.path, res.Header.Get("Content-Type"), test.contentType)
+		}
+	}
+
+	thumbnailMutex.Lock()
+	defer thumbnailMutex.Unlock()
+	if len(thumbnails) != 1 || len(thumbnails[mk4.Address].Image) == 0 {
+		t.Errorf("thumbnail of MK4 is not cached")
+	}
+}
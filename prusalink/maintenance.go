@@ -0,0 +1,378 @@
+package prusalink
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/rs/zerolog/log"
+)
+
+// Odometer is wear of one printer accumulated from snapshots, it is stored on disk so it survives restarts of the exporter
+// and of the printer. Components are axis, heaters (bed, chamber, tool id) and fans.
+type Odometer struct {
+	Printer         string             `json:"printer"`
+	Address         string             `json:"printer_address"`
+	PrintingSeconds float64            `json:"printing_seconds"`
+	AxisTravel      map[string]float64 `json:"axis_travel_meters"`
+	HeaterOn        map[string]float64 `json:"heater_on_seconds"`
+	FanRuntime      map[string]float64 `json:"fan_runtime_seconds"`
+	FilamentChanges float64            `json:"filament_changes"`
+	Serviced        map[string]float64 `json:"serviced"` // task -> value of its counter when the task was done
+	last            *PrinterSnapshot   // previous snapshot of the printer that is up
+	loaded          map[string]string  // printer ("") or tool -> name of the last loaded material
+	labels          map[string]string  // custom labels of the printer, they are not stored
+}
+
+// MaintenanceTaskState is state of one maintenance task of the printer
+type MaintenanceTaskState struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"` // value of the counter since the task was done
+	Every float64 `json:"every"`
+	Due   bool    `json:"due"`
+}
+
+// Maintenance is odometer of the printer together with its maintenance tasks
+type Maintenance struct {
+	Odometer
+	Tasks  []MaintenanceTaskState `json:"tasks"`
+	Labels map[string]string      `json:"labels,omitempty"`
+}
+
+var (
+	// defaultMaintenanceFile is where odometers are stored when file is not configured
+	defaultMaintenanceFile = "maintenance.json"
+
+	// maintenanceMaxGap is the longest time between two snapshots that is counted, longer gaps are downtime of the exporter
+	maintenanceMaxGap = 5 * time.Minute
+
+	// maintenanceCounters are counters that can be used by maintenance tasks
+	maintenanceCounters = map[string]func(odometer *Odometer) map[string]float64{
+		"printing_seconds":    func(odometer *Odometer) map[string]float64 { return map[string]float64{"": odometer.PrintingSeconds} },
+		"axis_travel_meters":  func(odometer *Odometer) map[string]float64 { return odometer.AxisTravel },
+		"heater_on_seconds":   func(odometer *Odometer) map[string]float64 { return odometer.HeaterOn },
+		"fan_runtime_seconds": func(odometer *Odometer) map[string]float64 { return odometer.FanRuntime },
+		"filament_changes":    func(odometer *Odometer) map[string]float64 { return map[string]float64{"": odometer.FilamentChanges} },
+	}
+
+	// odometerSaveInterval is how often changed odometers are written to the file, writing them on every scrape would wear SD cards
+	odometerSaveInterval = time.Minute
+
+	maintenanceMutex sync.Mutex
+	odometers        = map[string]*Odometer{} // printer address -> odometer
+	odometersDirty   bool                     // odometers changed since they were saved
+)
+
+// LoadOdometers reads odometers from the configured file, missing file means that there are no odometers yet
+func LoadOdometers() error {
+	maintenanceMutex.Lock()
+	defer maintenanceMutex.Unlock()
+
+	odometers, odometersDirty = map[string]*Odometer{}, false
+
+	for _, task := range configuration.Exporter.Maintenance.Tasks {
+		if maintenanceCounters[task.Counter] == nil {
+			log.Error().Msg("Unknown counter " + task.Counter + " of maintenance task " + task.Name)
+		}
+	}
+
+	data, err := os.ReadFile(getMaintenanceFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var stored []*Odometer
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+
+	for _, odometer := range stored {
+		odometers[odometer.Address] = odometer
+	}
+	return nil
+}
+
+// getMaintenanceFile returns path of the file with odometers
+func getMaintenanceFile() string {
+	if configuration.Exporter.Maintenance.File == "" {
+		return defaultMaintenanceFile
+	}
+	return configuration.Exporter.Maintenance.File
+}
+
+// saveOdometers writes all odometers to the configured file, it has to be called with maintenanceMutex locked.
+// File is replaced at once so it is never left half written.
+func saveOdometers() error {
+	stored := []*Odometer{}
+	for _, odometer := range odometers {
+		stored = append(stored, odometer)
+	}
+	sort.Slice(stored, func(i, j int) bool {
+		return stored[i].Address < stored[j].Address
+	})
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := getMaintenanceFile()
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return err
+	}
+
+	odometersDirty = false
+	return nil
+}
+
+// SaveOdometers writes odometers to the configured file when they changed since they were saved
+func SaveOdometers() error {
+	maintenanceMutex.Lock()
+	defer maintenanceMutex.Unlock()
+
+	if !odometersDirty {
+		return nil
+	}
+	return saveOdometers()
+}
+
+// RunOdometerSaves saves changed odometers in odometerSaveInterval, it never returns
+func RunOdometerSaves() {
+	for {
+		time.Sleep(odometerSaveInterval)
+		if err := SaveOdometers(); err != nil {
+			log.Error().Msg("Error while saving odometers to " + getMaintenanceFile() + " - " + err.Error())
+		}
+	}
+}
+
+// newOdometer returns empty odometer of the printer
+func newOdometer(printer config.Printers) *Odometer {
+	return &Odometer{
+		Printer:    GetPrinterID(printer),
+		Address:    printer.Address,
+		AxisTravel: map[string]float64{},
+		HeaterOn:   map[string]float64{},
+		FanRuntime: map[string]float64{},
+		Serviced:   map[string]float64{},
+	}
+}
+
+// UpdateOdometer adds wear between the previous and this snapshot of the printer to its odometer and stores odometers on disk.
+// Time when the printer was down or longer than maintenanceMaxGap is not counted.
+func UpdateOdometer(snapshot PrinterSnapshot) Odometer {
+	maintenanceMutex.Lock()
+	defer maintenanceMutex.Unlock()
+
+	odometer := odometers[snapshot.Printer.Address]
+	if odometer == nil {
+		odometer = newOdometer(snapshot.Printer)
+		odometers[snapshot.Printer.Address] = odometer
+	}
+	odometer.Printer = GetPrinterID(snapshot.Printer)
+	odometer.labels = configuration.GetPrinterLabels(snapshot.Printer)
+	if odometer.loaded == nil {
+		odometer.loaded = map[string]string{}
+	}
+
+	if !snapshot.Up {
+		odometer.last = nil
+		return odometer.clone()
+	}
+
+	odometer.countFilamentChanges(snapshot)
+
+	last := odometer.last
+	odometer.last = &snapshot
+	if last == nil {
+		return odometer.clone()
+	}
+
+	elapsed := snapshot.Time.Sub(last.Time)
+	if elapsed <= 0 || elapsed > maintenanceMaxGap {
+		return odometer.clone()
+	}
+	seconds := elapsed.Seconds()
+
+	if isPrinting(snapshot) {
+		odometer.PrintingSeconds += seconds
+	}
+
+	for _, axis := range snapshot.Axis {
+		for _, previous := range last.Axis {
+			if previous.Name == axis.Name {
+				odometer.AxisTravel[axis.Name] += math.Abs(axis.Position-previous.Position) / 1000
+			}
+		}
+	}
+
+	heaters := map[string]*Heater{"bed": snapshot.Bed, "chamber": snapshot.Chamber}
+	for i := range snapshot.Tools {
+		heaters["tool"+snapshot.Tools[i].ID] = &snapshot.Tools[i].Heater
+	}
+	for name, heater := range heaters {
+		if heater != nil && heater.Target > 0 {
+			odometer.HeaterOn[name] += seconds
+		}
+	}
+
+	fans := append([]Fan{}, snapshot.Fans...)
+	for _, tool := range snapshot.Tools {
+		for _, fan := range tool.Fans {
+			fans = append(fans, Fan{Name: "tool" + tool.ID + "_" + fan.Name, Speed: fan.Speed})
+		}
+	}
+	for _, fan := range fans {
+		if fan.Speed > 0 {
+			odometer.FanRuntime[fan.Name] += seconds
+		}
+	}
+
+	odometersDirty = true
+
+	return odometer.clone()
+}
+
+// countFilamentChanges counts loaded materials of the printer and its tools that differ from the last loaded material,
+// unloading is not a change
+func (odometer *Odometer) countFilamentChanges(snapshot PrinterSnapshot) {
+	materials := map[string]*Material{"": snapshot.Material}
+	for _, tool := range snapshot.Tools {
+		materials["tool"+tool.ID] = tool.Material
+	}
+
+	for key, material := range materials {
+		if material == nil || !material.Loaded {
+			continue
+		}
+		if last := odometer.loaded[key]; last != "" && last != material.Name {
+			odometer.FilamentChanges++
+		}
+		odometer.loaded[key] = material.Name
+	}
+}
+
+// clone returns copy of the odometer that can be read without maintenanceMutex
+func (odometer *Odometer) clone() Odometer {
+	clone := *odometer
+	clone.AxisTravel = maps.Clone(odometer.AxisTravel)
+	clone.HeaterOn = maps.Clone(odometer.HeaterOn)
+	clone.FanRuntime = maps.Clone(odometer.FanRuntime)
+	clone.Serviced = maps.Clone(odometer.Serviced)
+	clone.last, clone.loaded = nil, nil
+	return clone
+}
+
+// getMaintenanceTasks returns state of configured maintenance tasks of the odometer
+func getMaintenanceTasks(odometer Odometer) []MaintenanceTaskState {
+	tasks := []MaintenanceTaskState{}
+	for _, task := range configuration.Exporter.Maintenance.Tasks {
+		counter := maintenanceCounters[task.Counter]
+		if counter == nil {
+			continue
+		}
+
+		value := 0.0
+		for component, componentValue := range counter(&odometer) {
+			if task.Component == "" || task.Component == component {
+				value += componentValue
+			}
+		}
+		value -= odometer.Serviced[task.Name]
+
+		tasks = append(tasks, MaintenanceTaskState{Name: task.Name, Value: value, Every: task.Every, Due: task.Every > 0 && value >= task.Every})
+	}
+	return tasks
+}
+
+// markServiced records that the maintenance task of the printer was done, its counter starts again from 0.
+// It returns false when there is no such printer or task.
+func markServiced(id string, name string) (bool, error) {
+	maintenanceMutex.Lock()
+	defer maintenanceMutex.Unlock()
+
+	for _, odometer := range odometers {
+		if odometer.Printer != id && odometer.Address != id {
+			continue
+		}
+		for _, task := range getMaintenanceTasks(*odometer) {
+			if task.Name == name {
+				odometer.Serviced[name] += task.Value
+				return true, saveOdometers()
+			}
+		}
+	}
+	return false, nil
+}
+
+// GetMaintenance returns odometers and maintenance tasks of all printers sorted by address
+func GetMaintenance() []Maintenance {
+	maintenanceMutex.Lock()
+	defer maintenanceMutex.Unlock()
+
+	maintenance := []Maintenance{}
+	for _, odometer := range odometers {
+		maintenance = append(maintenance, Maintenance{Odometer: odometer.clone(), Tasks: getMaintenanceTasks(*odometer), Labels: odometer.labels})
+	}
+	sort.Slice(maintenance, func(i, j int) bool {
+		return maintenance[i].Address < maintenance[j].Address
+	})
+	return maintenance
+}
+
+// MaintenanceHandler returns handler of maintenance at the given prefix - e.g. /maintenance. GET <prefix>/ lists odometers
+// and tasks of all printers, POST <prefix>/<printer>/<task> records that the task was done.
+func MaintenanceHandler(prefix string) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	return http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(r.URL.Path, "/")
+
+		if path == "" {
+			writeJSON(w, GetMaintenance())
+			return
+		}
+
+		id, task, _ := strings.Cut(path, "/")
+		if r.Method != http.MethodPost || task == "" {
+			http.Error(w, "use POST "+prefix+"/<printer>/<task> to record done maintenance", http.StatusMethodNotAllowed)
+			return
+		}
+
+		found, err := markServiced(id, task)
+		if !found {
+			http.Error(w, "unknown printer "+id+" or maintenance task "+task, http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error().Msg("Error while saving odometers to " + getMaintenanceFile() + " - " + err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Info().Msg("Maintenance " + task + " of printer " + id + " done")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
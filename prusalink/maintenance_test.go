@@ -0,0 +1,117 @@
+package prusalink
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/pstrobl96/prusa_exporter/config"
+)
+
+func TestMaintenance(t *testing.T) {
+	printer := config.Printers{Address: "127.0.0.1:1", Name: "xl", Type: "XL"} // scrapes of the collector fail at once, odometer is updated by the test
+
+	var cfg config.Config
+	cfg.Exporter.Maintenance.Enabled = true
+	cfg.Exporter.Maintenance.File = filepath.Join(t.TempDir(), "maintenance.json")
+	cfg.Exporter.Maintenance.Tasks = []config.MaintenanceTask{
+		{Name: "lubricate_x", Counter: "axis_travel_meters", Component: "x", Every: 0.5},
+		{Name: "nozzle", Counter: "heater_on_seconds", Component: "tool0", Every: 3600},
+	}
+	cfg.Printers = []config.Printers{printer}
+	collector := NewCollector(cfg)
+
+	if err := LoadOdometers(); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	snapshot := func(offset time.Duration, state string, x float64, target float64, material string) PrinterSnapshot {
+		status := getStatePrinter(state)
+		return PrinterSnapshot{
+			Printer:   printer,
+			Up:        true,
+			Time:      start.Add(offset),
+			State:     status.State.Text,
+			StateCode: getStateFlag(status),
+			Axis:      []Axis{{Name: "x", Position: x}, {Name: "y", Position: 10}},
+			Tools:     []Tool{{ID: "0", Heater: Heater{Target: target}, Material: &Material{Name: material, Loaded: isLoaded(material)}}},
+		}
+	}
+
+	UpdateOdometer(snapshot(0, "IDLE", 0, 0, "PLA"))
+	UpdateOdometer(snapshot(time.Minute, "PRINTING", 300, 215, "PLA"))
+	UpdateOdometer(snapshot(2*time.Minute, "PRINTING", 100, 215, "---"))
+	UpdateOdometer(snapshot(3*time.Minute, "PRINTING", 400, 215, "PETG"))
+	UpdateOdometer(snapshot(time.Hour, "IDLE", 0, 0, "PETG")) // gap longer than maintenanceMaxGap is not counted
+	odometer := UpdateOdometer(snapshot(time.Hour+time.Minute, "IDLE", 0, 0, "PETG"))
+
+	if odometer.PrintingSeconds != 180 || odometer.HeaterOn["tool0"] != 180 {
+		t.Errorf("got %v printing and %v heater seconds, want 180 and 180", odometer.PrintingSeconds, odometer.HeaterOn["tool0"])
+	}
+	if math.Abs(odometer.AxisTravel["x"]-0.8) > 1e-9 || odometer.AxisTravel["y"] != 0 {
+		t.Errorf("got travel %v, want x 0.8 m and y 0", odometer.AxisTravel)
+	}
+	if odometer.FilamentChanges != 1 {
+		t.Errorf("got %v filament changes, want 1", odometer.FilamentChanges)
+	}
+
+	for name, want := range map[string]int{
+		"prusa_printing_seconds_total":   1,
+		"prusa_axis_travel_meters_total": 2,
+		"prusa_heater_on_seconds_total":  1,
+		"prusa_filament_changes_total":   1,
+		"prusa_maintenance_due":          2,
+	} {
+		if got := testutil.CollectAndCount(collector, name); got != want {
+			t.Errorf("got %d series of %s, want %d", got, name, want)
+		}
+	}
+
+	// odometers are not written on every scrape, only when they are saved
+	if _, err := os.Stat(cfg.Exporter.Maintenance.File); !os.IsNotExist(err) {
+		t.Errorf("odometers are written before they are saved - %v", err)
+	}
+	if err := SaveOdometers(); err != nil {
+		t.Fatal(err)
+	}
+
+	// odometers survive restart of the exporter
+	if err := LoadOdometers(); err != nil {
+		t.Fatal(err)
+	}
+	tasks := GetMaintenance()[0].Tasks
+	if !tasks[0].Due || tasks[1].Due {
+		t.Errorf("got tasks %+v, want only lubricate_x due", tasks)
+	}
+
+	server := httptest.NewServer(MaintenanceHandler("/maintenance"))
+	defer server.Close()
+
+	response, err := http.Post(server.URL+"/maintenance/xl/lubricate_x", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNoContent {
+		t.Errorf("got status %d of done maintenance, want 204", response.StatusCode)
+	}
+
+	if tasks := GetMaintenance()[0].Tasks; tasks[0].Due || tasks[0].Value != 0 {
+		t.Errorf("got task %+v after it was done, want not due with value 0", tasks[0])
+	}
+
+	response, err = http.Post(server.URL+"/maintenance/xl/unknown", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("got status %d of unknown task, want 404", response.StatusCode)
+	}
+}
@@ -0,0 +1,96 @@
+package prusalink
+
+import (
+	"context"
+	"errors"
+	"path"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/rs/zerolog/log"
+)
+
+// moonrakerStates maps state of print_stats of Klipper to state of /api/v1/status of PrusaLink
+var moonrakerStates = map[string]string{
+	"standby":   "IDLE",
+	"printing":  "PRINTING",
+	"paused":    "PAUSED",
+	"complete":  "FINISHED",
+	"cancelled": "STOPPED",
+	"error":     "ERROR",
+}
+
+// moonrakerSource reads Klipper printers from Moonraker API
+type moonrakerSource struct {
+	printer config.Printers
+}
+
+// Fetch implements Source
+func (source moonrakerSource) Fetch(ctx context.Context) (PrinterSnapshot, error) {
+	s := source.printer
+	log.Debug().Msg("Moonraker scraping at " + s.Address)
+
+	server, err := GetMoonrakerServer(ctx, s)
+	if err != nil {
+		return PrinterSnapshot{Printer: s}, errors.New("server info endpoint - " + err.Error())
+	}
+
+	info, err := GetMoonrakerInfo(ctx, s)
+	if err != nil {
+		return PrinterSnapshot{Printer: s}, errors.New("printer info endpoint - " + err.Error())
+	}
+
+	status, err := GetMoonrakerStatus(ctx, s)
+	if err != nil {
+		return PrinterSnapshot{Printer: s}, errors.New("printer objects endpoint - " + err.Error())
+	}
+
+	if err := ctx.Err(); err != nil {
+		return PrinterSnapshot{Printer: s}, err
+	}
+
+	klipper := status.Result.Status
+	stats := klipper.PrintStats
+
+	var job Job
+	if stats.Filename != "" {
+		job.Job.File.Name = path.Base(stats.Filename)
+		job.Job.File.Path = stats.Filename
+	}
+
+	state := moonrakerStates[stats.State]
+	if info.Result.State != "ready" {
+		state = "ERROR" // Klipper is in shutdown or error state and does not print
+	}
+
+	snapshot := newSnapshot(s, getStatePrinter(state), job)
+	snapshot.Info = &PrinterInfo{API: server.Result.APIVersionString, Server: server.Result.MoonrakerVersion,
+		Firmware: "Klipper " + info.Result.SoftwareVersion, Hostname: info.Result.Hostname}
+	snapshot.Bed = &Heater{Temp: klipper.HeaterBed.Temperature, Target: klipper.HeaterBed.Target}
+	snapshot.Tools = []Tool{{ID: "0", Heater: Heater{Temp: klipper.Extruder.Temperature, Target: klipper.Extruder.Target}}}
+	snapshot.PrintSpeed = ref(klipper.GcodeMove.SpeedFactor)
+	snapshot.Flow = ref(klipper.GcodeMove.ExtrudeFactor)
+
+	for i, axis := range []string{"x", "y", "z"} {
+		if i < len(klipper.Toolhead.Position) {
+			snapshot.Axis = append(snapshot.Axis, Axis{Name: axis, Position: klipper.Toolhead.Position[i]})
+		}
+	}
+
+	// Klipper reports speed of the fan as ratio, rpm is known only for fans with tachometer
+	if klipper.Fan.RPM != nil {
+		snapshot.Fans = []Fan{{Name: "print", Speed: *klipper.Fan.RPM}}
+	}
+
+	progress := klipper.DisplayStatus.Progress
+	remaining := 0.0
+	if progress > 0 && stats.State == "printing" {
+		remaining = stats.PrintDuration/progress - stats.PrintDuration // Moonraker has no estimate, it is computed from progress
+	}
+
+	snapshot.Job.Progress = ref(progress)
+	snapshot.Job.PrintTime = ref(stats.PrintDuration)
+	snapshot.Job.TimeRemaining = ref(remaining)
+
+	log.Debug().Msg("Scraping done at " + s.Address)
+	return snapshot, nil
+}
@@ -0,0 +1,78 @@
+package prusalink
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// metricNameModes are allowed values of metric_names - only legacy names, legacy and v2 names or only v2 names
+	metricNameModes = map[string]bool{
+		"legacy": true,
+		"both":   true,
+		"v2":     true,
+	}
+
+	// defaultMetricNames returns both names during deprecation period of legacy names
+	defaultMetricNames = "both"
+)
+
+// Rename is v2 name of a legacy metric following Prometheus naming conventions.
+// Legacy value is multiplied by Scale to get the base unit of v2 metric, e.g. 0.001 for milliamperes to amperes.
+type Rename struct {
+	Desc      *prometheus.Desc
+	ValueType prometheus.ValueType
+	Scale     float64
+}
+
+// MetricNames sends renamed metrics under legacy names, v2 names or both of them. Metrics without v2 name are always sent.
+type MetricNames struct {
+	mode    string
+	renames map[*prometheus.Desc]Rename
+}
+
+// NewMetricNames returns MetricNames for the given mode and v2 names of legacy descriptors, unknown mode falls back to default
+func NewMetricNames(mode string, renames map[*prometheus.Desc]Rename) *MetricNames {
+	if mode == "" {
+		mode = defaultMetricNames
+	} else if !metricNameModes[mode] {
+		log.Error().Msg("Unknown metric_names " + mode + ", using " + defaultMetricNames)
+		mode = defaultMetricNames
+	}
+	return &MetricNames{mode: mode, renames: renames}
+}
+
+// Legacy returns true when legacy names are sent
+func (names *MetricNames) Legacy() bool {
+	return names.mode != "v2"
+}
+
+// V2 returns true when v2 names are sent
+func (names *MetricNames) V2() bool {
+	return names.mode != "legacy"
+}
+
+// Describe sends descriptors of v2 names
+func (names *MetricNames) Describe(ch chan<- *prometheus.Desc) {
+	for _, rename := range names.renames {
+		ch <- rename.Desc
+	}
+}
+
+// Emit sends the metric under names selected by mode, label values are the same for both names
+func (names *MetricNames) Emit(ch chan<- prometheus.Metric, desc *prometheus.Desc, valueType prometheus.ValueType, value float64, labelValues ...string) {
+	rename, renamed := names.renames[desc]
+
+	if !renamed || names.Legacy() {
+		ch <- prometheus.MustNewConstMetric(desc, valueType, value, labelValues...)
+	}
+
+	if renamed && names.V2() {
+		ch <- prometheus.MustNewConstMetric(rename.Desc, rename.ValueType, value*rename.Scale, labelValues...)
+	}
+}
+
+// NewRename returns v2 name of a legacy metric with the given descriptor, value type and scale of the value
+func NewRename(desc *prometheus.Desc, valueType prometheus.ValueType, scale float64) Rename {
+	return Rename{Desc: desc, ValueType: valueType, Scale: scale}
+}
@@ -0,0 +1,53 @@
+package prusalink
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/rs/zerolog/log"
+)
+
+// octoPrintSource reads printers from OctoPrint API. /api/version, /api/job and /api/printer of OctoPrint
+// are the same as OctoPrint compatible endpoints of PrusaLink, only completion of the job is in percents instead of ratio.
+type octoPrintSource struct {
+	printer config.Printers
+}
+
+// Fetch implements Source
+func (source octoPrintSource) Fetch(ctx context.Context) (PrinterSnapshot, error) {
+	s := source.printer
+	log.Debug().Msg("OctoPrint scraping at " + s.Address)
+
+	version, err := GetVersion(ctx, s)
+	if err != nil {
+		return PrinterSnapshot{Printer: s}, errors.New("version endpoint - " + err.Error())
+	}
+
+	job, err := GetJob(ctx, s)
+	if err != nil {
+		return PrinterSnapshot{Printer: s}, errors.New("job endpoint - " + err.Error())
+	}
+
+	// OctoPrint returns 409 instead of printer data when it is not connected to the printer
+	printer, err := GetPrinter(ctx, s)
+	if err != nil {
+		return PrinterSnapshot{Printer: s}, errors.New("printer endpoint - " + err.Error())
+	}
+
+	if err := ctx.Err(); err != nil {
+		return PrinterSnapshot{Printer: s}, err
+	}
+
+	snapshot := newSnapshot(s, printer, job)
+	snapshot.Info = &PrinterInfo{API: version.API, Server: version.Server, Firmware: version.Text, Hostname: version.Hostname}
+	snapshot.Bed = &Heater{Temp: printer.Temperature.Bed.Actual, Target: printer.Temperature.Bed.Target, Offset: ref(printer.Temperature.Bed.Offset)}
+	snapshot.Tools = []Tool{{ID: "0", Heater: Heater{Temp: printer.Temperature.Tool0.Actual, Target: printer.Temperature.Tool0.Target,
+		Offset: ref(printer.Temperature.Tool0.Offset)}}}
+	snapshot.Job.Progress = ref(job.Progress.Completion / 100)
+	snapshot.Job.PrintTime = ref(job.Progress.PrintTime)
+	snapshot.Job.TimeRemaining = ref(job.Progress.PrintTimeLeft)
+
+	log.Debug().Msg("Scraping done at " + s.Address)
+	return snapshot, nil
+}
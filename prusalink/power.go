@@ -0,0 +1,151 @@
+package prusalink
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/pstrobl96/prusa_exporter/plug"
+	"github.com/rs/zerolog/log"
+)
+
+// Energy is energy consumed by one printer in joules integrated from its power, kWh is joules / 3 600 000
+type Energy struct {
+	Total     float64    `json:"total_joules"`
+	Job       *JobEnergy `json:"job,omitempty"`      // job that is printed now
+	LastJob   *JobEnergy `json:"last_job,omitempty"` // the last job that ended
+	lastPower *float64   // power of the previous snapshot
+	lastTime  time.Time
+}
+
+// JobEnergy is energy consumed by the printer while the job was printed
+type JobEnergy struct {
+	Name   string  `json:"name"`
+	Path   string  `json:"path"`
+	Joules float64 `json:"joules"`
+}
+
+// syslogPower is power of the printer computed from currents and voltages sent by syslog
+type syslogPower struct {
+	watts float64
+	time  time.Time
+}
+
+var (
+	// syslogPowerMaxAge is how long power sent by syslog is used, printer that stopped sending metrics has unknown power
+	syslogPowerMaxAge = time.Minute
+
+	// energyMaxGap is the longest time between two snapshots that is integrated, longer gaps are downtime of the exporter
+	energyMaxGap = 5 * time.Minute
+
+	powerMutex   sync.Mutex
+	syslogPowers = map[string]syslogPower{} // ip -> power
+	energies     = map[string]*Energy{}     // printer address -> energy
+)
+
+// SetSyslogPower stores power in W of the printer with the given ip address computed by syslog collector
+func SetSyslogPower(ip string, watts float64) {
+	powerMutex.Lock()
+	defer powerMutex.Unlock()
+
+	syslogPowers[ip] = syslogPower{watts: watts, time: time.Now()}
+}
+
+// getPower returns power of the printer in W and its source - plug when the printer has a smart plug and it can be read,
+// otherwise syslog. Nil means that power of the printer is unknown.
+func getPower(printer config.Printers) (*float64, string) {
+	if printer.Plug.Type != "" {
+		client, err := plug.NewClient(printer.Plug.Type, printer.Plug.Address, time.Duration(configuration.Exporter.ScrapeTimeout)*time.Millisecond)
+		if err == nil {
+			var power float64
+			if power, err = client.GetPower(); err == nil {
+				return &power, "plug"
+			}
+		}
+		log.Error().Msg("Error while reading smart plug of printer at " + printer.Address + " - " + err.Error())
+	}
+
+	powerMutex.Lock()
+	defer powerMutex.Unlock()
+
+	if power, ok := syslogPowers[addressHost(printer.Address)]; ok && time.Since(power.time) < syslogPowerMaxAge {
+		return &power.watts, "syslog"
+	}
+	return nil, ""
+}
+
+// updateEnergy integrates power between the previous and this snapshot of the printer with trapezoidal rule and returns
+// copy of its energy, false when power of the printer was never known. Energy between snapshots belongs to the job printed
+// at the previous one, job energy starts again when another job is printed and energy of the ended job is kept as the last job.
+func updateEnergy(snapshot PrinterSnapshot) (Energy, bool) {
+	powerMutex.Lock()
+	defer powerMutex.Unlock()
+
+	energy := energies[snapshot.Printer.Address]
+	if energy == nil {
+		if snapshot.Power == nil {
+			return Energy{}, false
+		}
+		energy = &Energy{}
+		energies[snapshot.Printer.Address] = energy
+	}
+
+	if snapshot.Power != nil && energy.lastPower != nil {
+		elapsed := snapshot.Time.Sub(energy.lastTime)
+		if elapsed > 0 && elapsed <= energyMaxGap {
+			joules := (*energy.lastPower + *snapshot.Power) / 2 * elapsed.Seconds()
+			energy.Total += joules
+			if energy.Job != nil {
+				energy.Job.Joules += joules
+			}
+		}
+	}
+
+	if snapshot.Up { // job of the printer that is down is not known, it is kept until the printer is up again
+		job := getActiveJob(snapshot)
+		if energy.Job != nil && (energy.Job.Name != job.Name || energy.Job.Path != job.Path) {
+			energy.LastJob, energy.Job = energy.Job, nil
+		}
+		if energy.Job == nil && (job.Name != "" || job.Path != "") {
+			energy.Job = &JobEnergy{Name: job.Name, Path: job.Path}
+		}
+	}
+	energy.lastPower, energy.lastTime = snapshot.Power, snapshot.Time
+
+	return energy.clone(), true
+}
+
+// getActiveJob returns job that is printed or paused, empty job when the printer does not print
+func getActiveJob(snapshot PrinterSnapshot) JobSnapshot {
+	if !isJobActive(snapshot) {
+		return JobSnapshot{}
+	}
+	return snapshot.Job
+}
+
+// clone returns copy of the energy that can be read without powerMutex
+func (energy *Energy) clone() Energy {
+	clone := *energy
+	if energy.Job != nil {
+		job := *energy.Job
+		clone.Job = &job
+	}
+	if energy.LastJob != nil {
+		job := *energy.LastJob
+		clone.LastJob = &job
+	}
+	clone.lastPower = nil
+	return clone
+}
+
+// GetEnergy returns energy consumed by the printer with the given address, false when its power was never known
+func GetEnergy(address string) (Energy, bool) {
+	powerMutex.Lock()
+	defer powerMutex.Unlock()
+
+	energy, ok := energies[address]
+	if !ok {
+		return Energy{}, false
+	}
+	return energy.clone(), true
+}
@@ -0,0 +1,64 @@
+package prusalink
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/pstrobl96/prusa_exporter/plug"
+)
+
+func TestEnergy(t *testing.T) {
+	energies, syslogPowers = map[string]*Energy{}, map[string]syslogPower{}
+	t.Cleanup(func() { energies, syslogPowers = map[string]*Energy{}, map[string]syslogPower{} })
+
+	standIn := plug.NewStandIn("shelly", 100)
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	printer := config.Printers{Address: "127.0.0.1:1", Name: "mk4", Type: "MK4", Plug: config.Plug{Type: "shelly", Address: server.URL}}
+
+	var cfg config.Config
+	cfg.Exporter.ScrapeTimeout = 1000
+	cfg.Printers = []config.Printers{printer}
+	collector := NewCollector(cfg)
+
+	start := time.Now()
+	snapshot := func(offset time.Duration, state string, job string) PrinterSnapshot {
+		power, source := getPower(printer)
+		if source != "plug" {
+			t.Fatalf("got power source %q, want plug", source)
+		}
+		status := getStatePrinter(state)
+		return PrinterSnapshot{Printer: printer, Up: true, Time: start.Add(offset), State: status.State.Text, StateCode: getStateFlag(status),
+			Job: JobSnapshot{Name: job}, Power: power}
+	}
+
+	updateEnergy(snapshot(0, "IDLE", ""))
+	updateEnergy(snapshot(time.Minute, "PRINTING", "benchy.bgcode")) // 6 kJ before the job starts
+	standIn.SetPower(200)
+	updateEnergy(snapshot(2*time.Minute, "PRINTING", "benchy.bgcode")) // 9 kJ of the job
+	updateEnergy(snapshot(time.Hour, "PRINTING", "benchy.bgcode"))     // gap longer than energyMaxGap is not integrated
+	// 12 kJ of the job until the printer is seen idle
+	energy, _ := updateEnergy(snapshot(time.Hour+time.Minute, "IDLE", ""))
+
+	if energy.Total != 27000 {
+		t.Errorf("got %v J in total, want 27000", energy.Total)
+	}
+	if energy.Job != nil || energy.LastJob == nil || energy.LastJob.Name != "benchy.bgcode" || energy.LastJob.Joules != 21000 {
+		t.Errorf("got job %+v and last job %+v, want no job and 21000 J of benchy.bgcode", energy.Job, energy.LastJob)
+	}
+
+	// scrapes of the printer fail at once, power is still read from the plug
+	for name, want := range map[string]int{
+		"prusa_power_watts":         1,
+		"prusa_energy_joules_total": 1,
+		"prusa_job_energy_joules":   0,
+	} {
+		if got := testutil.CollectAndCount(collector, name); got != want {
+			t.Errorf("got %d series of %s, want %d", got, name, want)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package prusalink
 
 import (
-	"strings"
+	"context"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/pstrobl96/prusa_exporter/config"
@@ -12,7 +14,6 @@ import (
 // Collector is a struct of all printer metrics
 type Collector struct {
 	printerBedTemp            *prometheus.Desc
-	printerPrintSpeed         *prometheus.Desc
 	printerFiles              *prometheus.Desc
 	printerPrintTime          *prometheus.Desc
 	printerPrintTimeRemaining *prometheus.Desc
@@ -44,21 +45,82 @@ type Collector struct {
 	printerFarmMode           *prometheus.Desc
 	printerCameras            *prometheus.Desc
 	printerFanSpeed           *prometheus.Desc
+	printerToolActive         *prometheus.Desc
+	printerToolNozzleSize     *prometheus.Desc
+	printerToolMaterial       *prometheus.Desc
+	printerToolFanSpeed       *prometheus.Desc
+	printerMMUSlotActive      *prometheus.Desc
+	printerMMUSlotMaterial    *prometheus.Desc
+	printerResinLevel         *prometheus.Desc
+	printerResinRemaining     *prometheus.Desc
+	printerResinUsed          *prometheus.Desc
+	printerResinLow           *prometheus.Desc
+	printerTankFull           *prometheus.Desc
+	printerLayerCurrent       *prometheus.Desc
+	printerLayers             *prometheus.Desc
+	printerExposureTime       *prometheus.Desc
+	printerTiltTime           *prometheus.Desc
+	printerUVLedUsage         *prometheus.Desc
+	printerStorageFree        *prometheus.Desc
+	printerStorageTotal       *prometheus.Desc
+	printerStorageAvailable   *prometheus.Desc
+	printerStorageReadOnly    *prometheus.Desc
+	printerStorageFilesSize   *prometheus.Desc
+	printerStorageFiles       *prometheus.Desc
+	printerFileSize           *prometheus.Desc
+	printerFileModified       *prometheus.Desc
+	printerCameraSnapshotAge  *prometheus.Desc
+	printerCameraSnapshotSize *prometheus.Desc
+	printerCameraFailures     *prometheus.Desc
+	printerJobInfo            *prometheus.Desc
+	printerPrintingSeconds    *prometheus.Desc
+	printerAxisTravel         *prometheus.Desc
+	printerHeaterOn           *prometheus.Desc
+	printerFanRuntime         *prometheus.Desc
+	printerFilamentChanges    *prometheus.Desc
+	printerMaintenanceDue     *prometheus.Desc
+	printerPower              *prometheus.Desc
+	printerEnergy             *prometheus.Desc
+	printerJobEnergy          *prometheus.Desc
+	printerJobCost            *prometheus.Desc
+	printerJobETA             *prometheus.Desc
+	printerEstimateError      *prometheus.Desc
+	jobLabels                 string
+	names                     *MetricNames
 }
 
 // NewCollector returns a new Collector for printer metrics
 func NewCollector(config config.Config) *Collector {
 	configuration = config
-	defaultLabels := []string{"printer_address", "printer_model", "printer_name", "printer_job_name", "printer_job_path"}
-	return &Collector{
+	jobLabels := getJobLabels()
+	customLabels = configuration.GetLabelNames()
+	printerLabels := slices.Clip(append([]string{"printer_address", "printer_model", "printer_name"}, customLabels...))
+	defaultLabels := printerLabels
+	if jobLabels == "all" {
+		defaultLabels = slices.Clip(append(printerLabels, "printer_job_name", "printer_job_path"))
+	}
+	collector := &Collector{
+		jobLabels:                 jobLabels,
+		printerJobInfo:            prometheus.NewDesc("prusa_job_info", "Returns 1 for the printed job, job labels can be joined to other metrics by printer_address.", append(printerLabels, "printer_job_name", "printer_job_path"), nil),
+		printerPrintingSeconds:    prometheus.NewDesc("prusa_printing_seconds_total", "Time the printer spent printing in seconds, stored on disk", printerLabels, nil),
+		printerAxisTravel:         prometheus.NewDesc("prusa_axis_travel_meters_total", "Distance travelled by the axis in meters derived from changes of its position, stored on disk", append(printerLabels, "printer_axis"), nil),
+		printerHeaterOn:           prometheus.NewDesc("prusa_heater_on_seconds_total", "Time the heater had a target temperature in seconds, stored on disk", append(printerLabels, "heater"), nil),
+		printerFanRuntime:         prometheus.NewDesc("prusa_fan_runtime_seconds_total", "Time the fan was spinning in seconds, stored on disk", append(printerLabels, "fan"), nil),
+		printerFilamentChanges:    prometheus.NewDesc("prusa_filament_changes_total", "Changes of loaded filament of the printer and its tools, stored on disk", printerLabels, nil),
+		printerMaintenanceDue:     prometheus.NewDesc("prusa_maintenance_due", "Returns 1 when the maintenance task is due", append(printerLabels, "task"), nil),
+		printerPower:              prometheus.NewDesc("prusa_power_watts", "Power of the printer in watts measured by smart plug or computed from syslog currents and voltages", append(printerLabels, "source"), nil),
+		printerEnergy:             prometheus.NewDesc("prusa_energy_joules_total", "Energy consumed by the printer in joules since start of the exporter", printerLabels, nil),
+		printerJobEnergy:          prometheus.NewDesc("prusa_job_energy_joules", "Energy consumed by the printer in joules while printing the current job", printerLabels, nil),
+		printerJobCost:            prometheus.NewDesc("prusa_job_cost", "Cost of the last ended job of the printer with the material in the configured currency", append(printerLabels, "material"), nil),
+		printerJobETA:             prometheus.NewDesc("prusa_job_eta_timestamp_seconds", "Unix time when the current job ends estimated from observed progress rate and time remaining of the firmware", printerLabels, nil),
+		printerEstimateError:      prometheus.NewDesc("prusa_job_estimate_error_ratio", "Mean relative error of slicer estimates of recent finished jobs, positive when jobs took longer than estimated", []string{"printer_model", "material"}, nil),
 		printerBedTemp:            prometheus.NewDesc("prusa_bed_temp", "Current temp of printer bed in Celsius", defaultLabels, nil),
-		printerPrintSpeed:         prometheus.NewDesc("prusa_print_speed_ratio", "Current setting of printer speed in ratio (0.0-1.0)", defaultLabels, nil),
 		printerFiles:              prometheus.NewDesc("prusa_files", "Number of files in storage", append(defaultLabels, "printer_storage"), nil),
 		printerPrintTimeRemaining: prometheus.NewDesc("prusa_printing_time_remaining", "Returns time that remains for completion of current print", defaultLabels, nil),
 		printerPrintProgress:      prometheus.NewDesc("prusa_printing_progress", "Returns information about completion of current print in percents", defaultLabels, nil),
 		printerMaterial:           prometheus.NewDesc("prusa_material", "Returns information about loaded filament. Returns 0 if there is no loaded filament", append(defaultLabels, "printer_filament"), nil),
 		printerPrintTime:          prometheus.NewDesc("prusa_print_time", "Returns information about current print time.", defaultLabels, nil),
-		printerUp:                 prometheus.NewDesc("prusa_up", "Return information about online printers. If printer is registered as offline then returned value is 0.", []string{"printer_address", "printer_model", "printer_name"}, nil),
+		printerUp:                 prometheus.NewDesc("prusa_up", "Return information about online printers. If printer is registered as offline then returned value is 0.", printerLabels, nil),
 		printerNozzleSize:         prometheus.NewDesc("prusa_nozzle_size", "Returns information about selected nozzle size.", defaultLabels, nil),
 		printerStatus:             prometheus.NewDesc("prusa_status", "Returns information status of printer.", append(defaultLabels, "printer_state"), nil),
 		printerAxis:               prometheus.NewDesc("prusa_axis", "Returns information about position of axis.", append(defaultLabels, "printer_axis"), nil),
@@ -66,11 +128,11 @@ func NewCollector(config config.Config) *Collector {
 		printerInfo:               prometheus.NewDesc("prusa_info", "Returns information about printer.", append(defaultLabels, "api_version", "server_version", "version_text", "prusalink_name", "printer_location", "serial_number", "printer_hostname"), nil),
 		printerMMU:                prometheus.NewDesc("prusa_mmu", "Returns information if MMU is enabled.", defaultLabels, nil),
 		printerFanSpeed:           prometheus.NewDesc("prusa_fan_speed", "Returns information about speed of hotend fan in rpm.", append(defaultLabels, "fan"), nil),
-		printerPrintSpeedRatio:    prometheus.NewDesc("prusa_print_speed_ratio", "Current setting of printer speed in values from 0.0 - 1.0", []string{"printer_address", "printer_model", "printer_name", "printer_job_name", "printer_job_path"}, nil),
-		printerLogs:               prometheus.NewDesc("prusa_logs", "Return size of logs in Prusa Link", []string{"printer_address", "printer_model", "printer_name", "printer_job_name", "printer_job_path", "log_name"}, nil),
-		printerLogsDate:           prometheus.NewDesc("prusa_logs_date", "Return date of logs in Prusa Link", []string{"printer_address", "printer_model", "printer_name", "printer_job_name", "printer_job_path", "log_name"}, nil),
-		printerFarmMode:           prometheus.NewDesc("prusa_farm_mode", "Return if printer is set to farm mode", []string{"printer_address", "printer_model", "printer_name", "printer_job_name", "printer_job_path"}, nil),
-		printerCameras:            prometheus.NewDesc("prusa_cameras", "Return information about cameras", []string{"printer_address", "printer_model", "printer_name", "printer_job_name", "printer_job_path", "camera_id", "camera_name", "camera_resolution"}, nil),
+		printerPrintSpeedRatio:    prometheus.NewDesc("prusa_print_speed_ratio", "Current setting of printer speed in values from 0.0 - 1.0", defaultLabels, nil),
+		printerLogs:               prometheus.NewDesc("prusa_logs", "Return size of logs in Prusa Link", append(defaultLabels, "log_name"), nil),
+		printerLogsDate:           prometheus.NewDesc("prusa_logs_date", "Return date of logs in Prusa Link", append(defaultLabels, "log_name"), nil),
+		printerFarmMode:           prometheus.NewDesc("prusa_farm_mode", "Return if printer is set to farm mode", defaultLabels, nil),
+		printerCameras:            prometheus.NewDesc("prusa_cameras", "Return information about cameras", append(defaultLabels, "camera_id", "camera_name", "camera_resolution"), nil),
 		printerCover:              prometheus.NewDesc("prusa_cover", "Status of the printer - 0 = open, 1 = closed", defaultLabels, nil),
 		printerAmbientTemp:        prometheus.NewDesc("prusa_ambient_temp", "Status of the printer ambient temp", defaultLabels, nil),
 		printerCPUTemp:            prometheus.NewDesc("prusa_cpu_temp", "Status of the printer cpu temp", defaultLabels, nil),
@@ -84,13 +146,65 @@ func NewCollector(config config.Config) *Collector {
 		printerToolTempTarget:     prometheus.NewDesc("prusa_tool_temp_target", "Target tool temp", append(defaultLabels, "tool"), nil),
 		printerToolTempOffset:     prometheus.NewDesc("prusa_tool_temp_offset", "Offset tool temp", append(defaultLabels, "tool"), nil),
 		printerHeatedChamber:      prometheus.NewDesc("prusa_heated_chamber", "Status of the printer heated chamber", defaultLabels, nil),
+		printerToolActive:         prometheus.NewDesc("prusa_tool_active", "Returns 1 for the tool that is currently picked, used for toolchangers like XL", append(defaultLabels, "tool"), nil),
+		printerToolNozzleSize:     prometheus.NewDesc("prusa_tool_nozzle_size", "Returns information about nozzle size of the tool", append(defaultLabels, "tool"), nil),
+		printerToolMaterial:       prometheus.NewDesc("prusa_tool_material", "Returns information about filament loaded in the tool. Returns 0 if there is no loaded filament", append(defaultLabels, "tool", "printer_filament"), nil),
+		printerToolFanSpeed:       prometheus.NewDesc("prusa_tool_fan_speed", "Returns information about speed of the tool fan in rpm", append(defaultLabels, "tool", "fan"), nil),
+		printerMMUSlotActive:      prometheus.NewDesc("prusa_mmu_slot_active", "Returns 1 for the MMU slot that is currently used, slots are numbered from 1", append(defaultLabels, "slot"), nil),
+		printerMMUSlotMaterial:    prometheus.NewDesc("prusa_mmu_slot_material", "Returns information about filament in the MMU slot. Returns 0 if there is no filament", append(defaultLabels, "slot", "printer_filament"), nil),
+		printerResinLevel:         prometheus.NewDesc("prusa_resin_level", "Level of resin in the tank in percents", defaultLabels, nil),
+		printerResinRemaining:     prometheus.NewDesc("prusa_resin_remaining", "Remaining resin in the tank in ml", defaultLabels, nil),
+		printerResinUsed:          prometheus.NewDesc("prusa_resin_used", "Resin consumed by the current job in ml", defaultLabels, nil),
+		printerResinLow:           prometheus.NewDesc("prusa_resin_low", "Warning that there is not enough resin in the tank - 0 = ok, 1 = low", defaultLabels, nil),
+		printerTankFull:           prometheus.NewDesc("prusa_tank_full", "Warning that the tank is overfilled - 0 = ok, 1 = full", defaultLabels, nil),
+		printerLayerCurrent:       prometheus.NewDesc("prusa_layer_current", "Layer that is currently printed", defaultLabels, nil),
+		printerLayers:             prometheus.NewDesc("prusa_layers", "Number of layers of the current job", defaultLabels, nil),
+		printerExposureTime:       prometheus.NewDesc("prusa_exposure_time", "Exposure time of the current job in seconds", append(defaultLabels, "exposure"), nil),
+		printerTiltTime:           prometheus.NewDesc("prusa_tilt_time", "Duration of the tank tilt in seconds", append(defaultLabels, "tilt"), nil),
+		printerUVLedUsage:         prometheus.NewDesc("prusa_uv_led_usage_seconds", "Usage of the UV LED in seconds", defaultLabels, nil),
+		printerStorageFree:        prometheus.NewDesc("prusa_storage_free_bytes", "Free space of the storage in bytes", append(defaultLabels, "printer_storage", "storage_type"), nil),
+		printerStorageTotal:       prometheus.NewDesc("prusa_storage_total_bytes", "Total space of the storage in bytes", append(defaultLabels, "printer_storage", "storage_type"), nil),
+		printerStorageAvailable:   prometheus.NewDesc("prusa_storage_available", "Status of the storage - 0 = missing, 1 = available", append(defaultLabels, "printer_storage", "storage_type"), nil),
+		printerStorageReadOnly:    prometheus.NewDesc("prusa_storage_read_only", "Returns 1 if the storage is read only", append(defaultLabels, "printer_storage", "storage_type"), nil),
+		printerStorageFilesSize:   prometheus.NewDesc("prusa_storage_files_size_bytes", "Size of print or system files in the storage in bytes", append(defaultLabels, "printer_storage", "storage_type", "files"), nil),
+		printerStorageFiles:       prometheus.NewDesc("prusa_storage_files", "Number of print files in the root folder of the storage", append(defaultLabels, "printer_storage", "storage_type"), nil),
+		printerFileSize:           prometheus.NewDesc("prusa_file_size_bytes", "Size of the file in the storage in bytes", append(defaultLabels, "printer_storage", "file_path", "file_name"), nil),
+		printerFileModified:       prometheus.NewDesc("prusa_file_modified_timestamp_seconds", "Time of the last modification of the file as unix timestamp", append(defaultLabels, "printer_storage", "file_path", "file_name"), nil),
+		printerCameraSnapshotAge:  prometheus.NewDesc("prusa_camera_snapshot_age_seconds", "Age of the latest snapshot of the camera in seconds", append(defaultLabels, "camera_id", "camera_name"), nil),
+		printerCameraSnapshotSize: prometheus.NewDesc("prusa_camera_snapshot_size_bytes", "Size of the latest snapshot of the camera in bytes", append(defaultLabels, "camera_id", "camera_name"), nil),
+		printerCameraFailures:     prometheus.NewDesc("prusa_camera_snapshot_failures_total", "Number of failed downloads of camera snapshots", append(defaultLabels, "camera_id", "camera_name"), nil),
 	}
+
+	gauge := prometheus.GaugeValue
+	collector.names = NewMetricNames(configuration.Exporter.MetricNames, map[*prometheus.Desc]Rename{
+		collector.printerBedTemp:            NewRename(prometheus.NewDesc("prusa_bed_temperature_celsius", "Current temperature of the bed in Celsius", defaultLabels, nil), gauge, 1),
+		collector.printerBedTempTarget:      NewRename(prometheus.NewDesc("prusa_bed_target_temperature_celsius", "Target temperature of the bed in Celsius", defaultLabels, nil), gauge, 1),
+		collector.printerBedTempOffset:      NewRename(prometheus.NewDesc("prusa_bed_temperature_offset_celsius", "Offset of the bed temperature in Celsius", defaultLabels, nil), gauge, 1),
+		collector.printerChamberTemp:        NewRename(prometheus.NewDesc("prusa_chamber_temperature_celsius", "Current temperature of the chamber in Celsius", defaultLabels, nil), gauge, 1),
+		collector.printerChamberTempTarget:  NewRename(prometheus.NewDesc("prusa_chamber_target_temperature_celsius", "Target temperature of the chamber in Celsius", defaultLabels, nil), gauge, 1),
+		collector.printerChamberTempOffset:  NewRename(prometheus.NewDesc("prusa_chamber_temperature_offset_celsius", "Offset of the chamber temperature in Celsius", defaultLabels, nil), gauge, 1),
+		collector.printerToolTemp:           NewRename(prometheus.NewDesc("prusa_tool_temperature_celsius", "Current temperature of the tool in Celsius", append(defaultLabels, "tool"), nil), gauge, 1),
+		collector.printerToolTempTarget:     NewRename(prometheus.NewDesc("prusa_tool_target_temperature_celsius", "Target temperature of the tool in Celsius", append(defaultLabels, "tool"), nil), gauge, 1),
+		collector.printerToolTempOffset:     NewRename(prometheus.NewDesc("prusa_tool_temperature_offset_celsius", "Offset of the tool temperature in Celsius", append(defaultLabels, "tool"), nil), gauge, 1),
+		collector.printerAmbientTemp:        NewRename(prometheus.NewDesc("prusa_ambient_temperature_celsius", "Ambient temperature of resin printer in Celsius", defaultLabels, nil), gauge, 1),
+		collector.printerCPUTemp:            NewRename(prometheus.NewDesc("prusa_cpu_temperature_celsius", "Temperature of CPU of resin printer in Celsius", defaultLabels, nil), gauge, 1),
+		collector.pritnerUVTemp:             NewRename(prometheus.NewDesc("prusa_uv_led_temperature_celsius", "Temperature of UV LED of resin printer in Celsius", defaultLabels, nil), gauge, 1),
+		collector.printerPrintTime:          NewRename(prometheus.NewDesc("prusa_print_time_seconds", "Time of the current print in seconds", defaultLabels, nil), gauge, 1),
+		collector.printerPrintTimeRemaining: NewRename(prometheus.NewDesc("prusa_print_time_remaining_seconds", "Time that remains for completion of the current print in seconds", defaultLabels, nil), gauge, 1),
+		collector.printerPrintProgress:      NewRename(prometheus.NewDesc("prusa_print_progress_ratio", "Completion of the current print from 0.0 to 1.0", defaultLabels, nil), gauge, 1),
+		collector.printerFanSpeed:           NewRename(prometheus.NewDesc("prusa_fan_speed_rpm", "Speed of the fan in rpm", append(defaultLabels, "fan"), nil), gauge, 1),
+		collector.printerToolFanSpeed:       NewRename(prometheus.NewDesc("prusa_tool_fan_speed_rpm", "Speed of the tool fan in rpm", append(defaultLabels, "tool", "fan"), nil), gauge, 1),
+		collector.printerResinLevel:         NewRename(prometheus.NewDesc("prusa_resin_level_ratio", "Level of resin in the tank from 0.0 to 1.0", defaultLabels, nil), gauge, 0.01),
+		collector.printerExposureTime:       NewRename(prometheus.NewDesc("prusa_exposure_time_seconds", "Exposure time of the current job in seconds", append(defaultLabels, "exposure"), nil), gauge, 1),
+		collector.printerTiltTime:           NewRename(prometheus.NewDesc("prusa_tilt_time_seconds", "Duration of the tank tilt in seconds", append(defaultLabels, "tilt"), nil), gauge, 1),
+	})
+
+	return collector
 }
 
 // Describe implements prometheus.Collector
 func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.printerBedTemp
-	ch <- collector.printerPrintSpeed
 	ch <- collector.printerFiles
 	ch <- collector.printerPrintTime
 	ch <- collector.printerPrintTimeRemaining
@@ -121,303 +235,331 @@ func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.printerLogsDate
 	ch <- collector.printerLogs
 	ch <- collector.printerFanSpeed
+	ch <- collector.printerPrintSpeedRatio
+	ch <- collector.printerToolActive
+	ch <- collector.printerToolNozzleSize
+	ch <- collector.printerToolMaterial
+	ch <- collector.printerToolFanSpeed
+	ch <- collector.printerMMUSlotActive
+	ch <- collector.printerMMUSlotMaterial
+	ch <- collector.printerResinLevel
+	ch <- collector.printerResinRemaining
+	ch <- collector.printerResinUsed
+	ch <- collector.printerResinLow
+	ch <- collector.printerTankFull
+	ch <- collector.printerLayerCurrent
+	ch <- collector.printerLayers
+	ch <- collector.printerExposureTime
+	ch <- collector.printerTiltTime
+	ch <- collector.printerUVLedUsage
+	ch <- collector.printerStorageFree
+	ch <- collector.printerStorageTotal
+	ch <- collector.printerStorageAvailable
+	ch <- collector.printerStorageReadOnly
+	ch <- collector.printerStorageFilesSize
+	ch <- collector.printerStorageFiles
+	ch <- collector.printerFileSize
+	ch <- collector.printerFileModified
+	ch <- collector.printerCameraSnapshotAge
+	ch <- collector.printerCameraSnapshotSize
+	ch <- collector.printerCameraFailures
+	ch <- collector.printerJobInfo
+	ch <- collector.printerPrintingSeconds
+	ch <- collector.printerAxisTravel
+	ch <- collector.printerHeaterOn
+	ch <- collector.printerFanRuntime
+	ch <- collector.printerFilamentChanges
+	ch <- collector.printerMaintenanceDue
+	ch <- collector.printerPower
+	ch <- collector.printerEnergy
+	ch <- collector.printerJobEnergy
+	ch <- collector.printerJobCost
+	ch <- collector.printerJobETA
+	ch <- collector.printerEstimateError
+	collector.names.Describe(ch)
+}
+
+// scrapeContext returns context of one printer scrape, requests to the printer are cancelled when scrape timeout passes
+func scrapeContext() (context.Context, context.CancelFunc) {
+	timeout := time.Duration(configuration.Exporter.ScrapeTimeout) * time.Millisecond
+	if timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), timeout)
 }
 
 // Collect implements prometheus.Collector
 func (collector *Collector) Collect(ch chan<- prometheus.Metric) {
 
 	var wg sync.WaitGroup
-	for _, s := range configuration.Printers {
+	for _, s := range GetPrinters() {
 		wg.Add(1)
 		go func(s config.Printers) {
 			defer wg.Done()
 
-			log.Debug().Msg("Printer scraping at " + s.Address)
-			printerUp := prometheus.MustNewConstMetric(collector.printerUp, prometheus.GaugeValue,
-				0, s.Address, s.Type, s.Name)
-
-			if s.Type == "" {
-				printerType, err := GetPrinterType(s)
-				if err != nil {
-					log.Error().Msg("Error while probing printer at " + s.Address + " - " + err.Error())
-					ch <- printerUp
-					return
-				}
-				s.Type = printerType
-			}
+			ctx, cancel := scrapeContext()
+			defer cancel()
 
-			job, err := GetJob(s)
+			snapshot, err := NewSource(s).Fetch(ctx)
 			if err != nil {
-				log.Error().Msg("Error while scraping job endpoint at " + s.Address + " - " + err.Error())
-				ch <- printerUp
-				return
+				log.Error().Msg("Error while scraping printer at " + s.Address + " - " + err.Error())
 			}
-
-			printer, err := GetPrinter(s)
-			if err != nil {
-				log.Error().Msg("Error while scraping printer endpoint at " + s.Address + " - " + err.Error())
-				ch <- printerUp
-				return
-			}
-
-			files, err := GetFiles(s)
-			if err != nil {
-				log.Error().Msg("Error while scraping files endpoint at " + s.Address + " - " + err.Error())
-				ch <- printerUp
-				return
-			}
-
-			version, err := GetVersion(s)
-			if err != nil {
-				log.Error().Msg("Error while scraping version endpoint at " + s.Address + " - " + err.Error())
-				ch <- printerUp
-				return
+			if snapshot.Time.IsZero() {
+				snapshot.Time = now()
 			}
+			snapshot.Power, snapshot.PowerSource = getPower(s)
+			collector.emit(ch, snapshot)
+		}(s)
+	}
 
-			// metrics specific for both buddy and einsy
-			if printerBoards[s.Type] == "buddy" || printerBoards[s.Type] == "einsy" {
-
-				status, err := GetStatus(s)
-
-				if err != nil {
-					log.Error().Msg("Error while scraping status endpoint at " + s.Address + " - " + err.Error())
-				}
-
-				info, err := GetInfo(s)
-
-				if err != nil {
-					log.Error().Msg("Error while scraping info endpoint at " + s.Address + " - " + err.Error())
-				}
-
-				// only einsy related metrics
-				if printerBoards[s.Type] == "einsy" {
-					settings, err := GetSettings(s)
-
-					if err != nil {
-						log.Error().Msg("Error while scraping settings endpoint at " + s.Address + " - " + err.Error())
-					} else {
-
-						printerFarmMode := prometheus.MustNewConstMetric(
-							collector.printerFarmMode, prometheus.GaugeValue,
-							BoolToFloat(settings.Printer.FarmMode),
-							GetLabels(s, job)...)
-
-						ch <- printerFarmMode
-
-					}
-
-					cameras, err := GetCameras(s)
-
-					if err != nil {
-						log.Error().Msg("Error while scraping cameras endpoint at " + s.Address + " - " + err.Error())
-					} else {
-
-						for _, v := range cameras.CameraList {
-							printerCamera := prometheus.MustNewConstMetric(
-								collector.printerCameras, prometheus.GaugeValue,
-								BoolToFloat(v.Connected),
-								GetLabels(s, job, v.CameraID, v.Config.Name, v.Config.Resolution)...)
-							ch <- printerCamera
-						}
-					}
-
-					for _, v := range files.Files {
-						printerFiles := prometheus.MustNewConstMetric(
-							collector.printerFiles, prometheus.GaugeValue,
-							float64(len(v.Children)),
-							GetLabels(s, job, v.Display)...)
-						ch <- printerFiles
-					}
-
-				}
-
-				printerInfo := prometheus.MustNewConstMetric(
-					collector.printerInfo, prometheus.GaugeValue,
-					1,
-					GetLabels(s, job, version.API, version.Server, version.Text, info.Name, info.Location, info.Serial, info.Hostname)...)
-
-				ch <- printerInfo
-
-				printerFanHotend := prometheus.MustNewConstMetric(collector.printerFanSpeed, prometheus.GaugeValue,
-					status.Printer.FanHotend, GetLabels(s, job, "hotend")...)
-
-				ch <- printerFanHotend
-
-				printerFanPrint := prometheus.MustNewConstMetric(collector.printerFanSpeed, prometheus.GaugeValue,
-					status.Printer.FanPrint, GetLabels(s, job, "print")...)
-
-				ch <- printerFanPrint
-
-				printerNozzleSize := prometheus.MustNewConstMetric(collector.printerNozzleSize, prometheus.GaugeValue,
-					info.NozzleDiameter, GetLabels(s, job)...)
-
-				ch <- printerNozzleSize
-
-				printSpeed := prometheus.MustNewConstMetric(
-					collector.printerPrintSpeedRatio, prometheus.GaugeValue,
-					printer.Telemetry.PrintSpeed/100,
-					s.Address, s.Type, s.Name, job.Job.File.Name, job.Job.File.Path)
-
-				ch <- printSpeed
-
-				printTime := prometheus.MustNewConstMetric(
-					collector.printerPrintTime, prometheus.GaugeValue,
-					job.Progress.PrintTime,
-					s.Address, s.Type, s.Name, job.Job.File.Name, job.Job.File.Path)
-
-				ch <- printTime
-
-				printTimeRemaining := prometheus.MustNewConstMetric(
-					collector.printerPrintTimeRemaining, prometheus.GaugeValue,
-					job.Progress.PrintTimeLeft,
-					s.Address, s.Type, s.Name, job.Job.File.Name, job.Job.File.Path)
-
-				ch <- printTimeRemaining
-
-				printProgress := prometheus.MustNewConstMetric(
-					collector.printerPrintProgress, prometheus.GaugeValue,
-					job.Progress.Completion,
-					s.Address, s.Type, s.Name, job.Job.File.Name, job.Job.File.Path)
-
-				ch <- printProgress
-
-				material := prometheus.MustNewConstMetric(
-					collector.printerMaterial, prometheus.GaugeValue,
-					BoolToFloat(!(strings.Contains(printer.Telemetry.Material, "-"))),
-					s.Address, s.Type, s.Name, job.Job.File.Name, job.Job.File.Path, printer.Telemetry.Material)
-
-				ch <- material
-
-				printerAxisX := prometheus.MustNewConstMetric(
-					collector.printerAxis, prometheus.GaugeValue,
-					printer.Telemetry.AxisX,
-					GetLabels(s, job, "x")...)
-
-				ch <- printerAxisX
-
-				printerAxisY := prometheus.MustNewConstMetric(
-					collector.printerAxis, prometheus.GaugeValue,
-					printer.Telemetry.AxisY,
-					GetLabels(s, job, "y")...)
-
-				ch <- printerAxisY
-
-				printerAxisZ := prometheus.MustNewConstMetric(
-					collector.printerAxis, prometheus.GaugeValue,
-					printer.Telemetry.AxisZ,
-					GetLabels(s, job, "z")...)
-
-				ch <- printerAxisZ
-
-				printerFlow := prometheus.MustNewConstMetric(collector.printerFlow, prometheus.GaugeValue,
-					status.Printer.Flow/100, GetLabels(s, job)...)
+	if configuration.Exporter.Connect.Enabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collector.collectConnect(ch)
+		}()
+	}
 
-				ch <- printerFlow
+	wg.Wait()
 
-				if printerBoards[s.Type] == "buddy" {
-					printerMMU := prometheus.MustNewConstMetric(collector.printerMMU, prometheus.GaugeValue,
-						BoolToFloat(info.Mmu), GetLabels(s, job)...)
-					ch <- printerMMU
-				}
-			}
+	if configuration.Exporter.Fleet.Enabled {
+		pruneFleet(GetPrinters(), now())
+	}
 
-			// only sl related metrics
-			if printerBoards[s.Type] == "sl" {
-				printerCover := prometheus.MustNewConstMetric(collector.printerCover, prometheus.GaugeValue,
-					BoolToFloat(printer.Telemetry.CoverClosed), GetLabels(s, job)...)
+	for _, estimate := range getEstimateErrors() {
+		ch <- prometheus.MustNewConstMetric(collector.printerEstimateError, prometheus.GaugeValue, estimate.ratio, estimate.model, estimate.material)
+	}
+}
 
-				ch <- printerCover
+// getJobLabels returns where printer_job_name and printer_job_path labels are returned, unknown value falls back to default
+func getJobLabels() string {
+	mode := configuration.Exporter.Prusalink.JobLabels
+	if mode == "" {
+		return defaultJobLabels
+	}
+	if !jobLabelModes[mode] {
+		log.Error().Msg("Unknown job_labels " + mode + ", using " + defaultJobLabels)
+		return defaultJobLabels
+	}
+	return mode
+}
 
-				printerFanBlower := prometheus.MustNewConstMetric(collector.printerFanSpeed, prometheus.GaugeValue,
-					printer.Telemetry.FanBlower, GetLabels(s, job, "blower")...)
+// emitMaintenance returns odometer counters and due maintenance tasks of the printer, they are returned also when the printer is down
+func (collector *Collector) emitMaintenance(ch chan<- prometheus.Metric, s config.Printers, odometer Odometer) {
+	counter := func(desc *prometheus.Desc, value float64, labelValues ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value, getPrinterLabels(s, labelValues...)...)
+	}
 
-				ch <- printerFanBlower
+	counter(collector.printerPrintingSeconds, odometer.PrintingSeconds)
+	counter(collector.printerFilamentChanges, odometer.FilamentChanges)
+	for axis, travel := range odometer.AxisTravel {
+		counter(collector.printerAxisTravel, travel, axis)
+	}
+	for heater, seconds := range odometer.HeaterOn {
+		counter(collector.printerHeaterOn, seconds, heater)
+	}
+	for fan, seconds := range odometer.FanRuntime {
+		counter(collector.printerFanRuntime, seconds, fan)
+	}
 
-				printerFanRear := prometheus.MustNewConstMetric(collector.printerFanSpeed, prometheus.GaugeValue,
-					printer.Telemetry.FanRear, GetLabels(s, job, "rear")...)
+	for _, task := range getMaintenanceTasks(odometer) {
+		ch <- prometheus.MustNewConstMetric(collector.printerMaintenanceDue, prometheus.GaugeValue, BoolToFloat(task.Due), getPrinterLabels(s, task.Name)...)
+	}
+}
 
-				ch <- printerFanRear
+// emitPower returns power and energy of the printer, they are returned also when the printer is down because smart plug
+// measures it anyway
+func (collector *Collector) emitPower(ch chan<- prometheus.Metric, s config.Printers, snapshot PrinterSnapshot, energy Energy) {
+	if snapshot.Power != nil {
+		ch <- prometheus.MustNewConstMetric(collector.printerPower, prometheus.GaugeValue, *snapshot.Power, getPrinterLabels(s, snapshot.PowerSource)...)
+	}
+	ch <- prometheus.MustNewConstMetric(collector.printerEnergy, prometheus.CounterValue, energy.Total, getPrinterLabels(s)...)
+	if energy.Job != nil {
+		ch <- prometheus.MustNewConstMetric(collector.printerJobEnergy, prometheus.GaugeValue, energy.Job.Joules, getPrinterLabels(s)...)
+	}
+}
 
-				printerFanUV := prometheus.MustNewConstMetric(collector.printerFanSpeed, prometheus.GaugeValue,
-					printer.Telemetry.FanUvLed, GetLabels(s, job, "uv")...)
+// emit turns snapshot of the printer into metrics, printer that is not up returns only prusa_up
+func (collector *Collector) emit(ch chan<- prometheus.Metric, snapshot PrinterSnapshot) {
+	s := snapshot.Printer
 
-				ch <- printerFanUV
+	if configuration.Exporter.Maintenance.Enabled {
+		collector.emitMaintenance(ch, s, UpdateOdometer(snapshot))
+	}
 
-				printerAmbientTemp := prometheus.MustNewConstMetric(collector.printerAmbientTemp, prometheus.GaugeValue,
-					printer.Telemetry.TempAmbient, GetLabels(s, job)...)
+	energy, ok := updateEnergy(snapshot)
+	if ok {
+		collector.emitPower(ch, s, snapshot, energy)
+	}
 
-				ch <- printerAmbientTemp
+	if configuration.Exporter.Costs.Enabled {
+		updateJobCost(snapshot, energy)
+		for material, cost := range getLastJobCosts(s.Address) {
+			ch <- prometheus.MustNewConstMetric(collector.printerJobCost, prometheus.GaugeValue, cost, getPrinterLabels(s, material)...)
+		}
+	}
 
-				printerCPUTemp := prometheus.MustNewConstMetric(collector.printerCPUTemp, prometheus.GaugeValue,
-					printer.Telemetry.TempCPU, GetLabels(s, job)...)
+	if configuration.Exporter.Fleet.Enabled {
+		recordFleet(snapshot)
+	}
 
-				ch <- printerCPUTemp
+	if eta := updateETA(snapshot); eta != nil {
+		ch <- prometheus.MustNewConstMetric(collector.printerJobETA, prometheus.GaugeValue, float64(eta.UnixMilli())/1000, getPrinterLabels(s)...)
+	}
 
-				pritnerUVTemp := prometheus.MustNewConstMetric(collector.pritnerUVTemp, prometheus.GaugeValue,
-					printer.Telemetry.TempUvLed, GetLabels(s, job)...)
+	if !snapshot.Up {
+		ch <- prometheus.MustNewConstMetric(collector.printerUp, prometheus.GaugeValue, 0, getPrinterLabels(s)...)
+		return
+	}
 
-				ch <- pritnerUVTemp
+	labels := func(labelValues ...string) []string {
+		if collector.jobLabels == "all" {
+			return getPrinterLabels(s, append([]string{snapshot.Job.Name, snapshot.Job.Path}, labelValues...)...)
+		}
+		return getPrinterLabels(s, labelValues...)
+	}
+	gauge := func(desc *prometheus.Desc, value float64, labelValues ...string) {
+		collector.names.Emit(ch, desc, prometheus.GaugeValue, value, labels(labelValues...)...)
+	}
+	optional := func(desc *prometheus.Desc, value *float64, labelValues ...string) {
+		if value != nil {
+			gauge(desc, *value, labelValues...)
+		}
+	}
+	flag := func(desc *prometheus.Desc, value *bool, labelValues ...string) {
+		if value != nil {
+			gauge(desc, BoolToFloat(*value), labelValues...)
+		}
+	}
 
-				printerChamberTempTarget := prometheus.MustNewConstMetric(collector.printerChamberTempTarget, prometheus.GaugeValue,
-					printer.Temperature.Chamber.Target, GetLabels(s, job)...)
+	if info := snapshot.Info; info != nil {
+		gauge(collector.printerInfo, 1, info.API, info.Server, info.Firmware, info.Name, info.Location, info.Serial, info.Hostname)
+	}
 
-				ch <- printerChamberTempTarget
+	gauge(collector.printerStatus, snapshot.StateCode, snapshot.State)
 
-				printerChamberTempOffset := prometheus.MustNewConstMetric(collector.printerChamberTempOffset, prometheus.GaugeValue,
-					printer.Temperature.Chamber.Offset, GetLabels(s, job)...)
+	if collector.jobLabels == "info" && snapshot.Job.Name != "" {
+		ch <- prometheus.MustNewConstMetric(collector.printerJobInfo, prometheus.GaugeValue,
+			1, getPrinterLabels(s, snapshot.Job.Name, snapshot.Job.Path)...)
+	}
 
-				ch <- printerChamberTempOffset
+	if bed := snapshot.Bed; bed != nil {
+		gauge(collector.printerBedTemp, bed.Temp)
+		gauge(collector.printerBedTempTarget, bed.Target)
+		optional(collector.printerBedTempOffset, bed.Offset)
+	}
 
-				printerChamberTemp := prometheus.MustNewConstMetric(collector.printerChamberTemp, prometheus.GaugeValue,
-					printer.Temperature.Chamber.Actual, GetLabels(s, job)...)
+	if chamber := snapshot.Chamber; chamber != nil {
+		gauge(collector.printerChamberTemp, chamber.Temp)
+		gauge(collector.printerChamberTempTarget, chamber.Target)
+		optional(collector.printerChamberTempOffset, chamber.Offset)
+	}
 
-				ch <- printerChamberTemp
-			}
+	for _, tool := range snapshot.Tools {
+		gauge(collector.printerToolTemp, tool.Temp, tool.ID)
+		gauge(collector.printerToolTempTarget, tool.Target, tool.ID)
+		optional(collector.printerToolTempOffset, tool.Offset, tool.ID)
+		flag(collector.printerToolActive, tool.Active, tool.ID)
+		optional(collector.printerToolNozzleSize, tool.NozzleDiameter, tool.ID)
 
-			printerBedTemp := prometheus.MustNewConstMetric(collector.printerBedTemp, prometheus.GaugeValue,
-				printer.Temperature.Bed.Actual, GetLabels(s, job)...)
+		if tool.Material != nil {
+			gauge(collector.printerToolMaterial, BoolToFloat(tool.Material.Loaded), tool.ID, tool.Material.Name)
+		}
 
-			ch <- printerBedTemp
+		for _, fan := range tool.Fans {
+			gauge(collector.printerToolFanSpeed, fan.Speed, tool.ID, fan.Name)
+		}
+	}
 
-			printerBedTempTarget := prometheus.MustNewConstMetric(collector.printerBedTempTarget, prometheus.GaugeValue,
-				printer.Temperature.Bed.Target, GetLabels(s, job)...)
+	for _, fan := range snapshot.Fans {
+		gauge(collector.printerFanSpeed, fan.Speed, fan.Name)
+	}
 
-			ch <- printerBedTempTarget
+	for _, axis := range snapshot.Axis {
+		gauge(collector.printerAxis, axis.Position, axis.Name)
+	}
 
-			printerBedTempOffset := prometheus.MustNewConstMetric(collector.printerBedTempOffset, prometheus.GaugeValue,
-				printer.Temperature.Bed.Offset, GetLabels(s, job)...)
+	optional(collector.printerNozzleSize, snapshot.NozzleDiameter)
+	optional(collector.printerPrintSpeedRatio, snapshot.PrintSpeed)
+	optional(collector.printerFlow, snapshot.Flow)
+	flag(collector.printerFarmMode, snapshot.FarmMode)
+	flag(collector.printerMMU, snapshot.MMU)
 
-			ch <- printerBedTempOffset
+	if material := snapshot.Material; material != nil {
+		gauge(collector.printerMaterial, BoolToFloat(material.Loaded), material.Name)
+	}
 
-			printerStatus := prometheus.MustNewConstMetric(
-				collector.printerStatus, prometheus.GaugeValue,
-				getStateFlag(printer),
-				s.Address, s.Type, s.Name, job.Job.File.Name, job.Job.File.Path, printer.State.Text)
+	for _, slot := range snapshot.MMUSlots {
+		gauge(collector.printerMMUSlotActive, BoolToFloat(slot.Active), slot.Slot)
+		gauge(collector.printerMMUSlotMaterial, BoolToFloat(slot.Material.Loaded), slot.Slot, slot.Material.Name)
+	}
 
-			ch <- printerStatus
+	optional(collector.printerPrintProgress, snapshot.Job.Progress)
+	optional(collector.printerPrintTime, snapshot.Job.PrintTime)
+	optional(collector.printerPrintTimeRemaining, snapshot.Job.TimeRemaining)
+
+	if sl := snapshot.SL; sl != nil {
+		gauge(collector.printerCover, BoolToFloat(sl.Cover))
+		gauge(collector.printerAmbientTemp, sl.AmbientTemp)
+		gauge(collector.printerCPUTemp, sl.CPUTemp)
+		gauge(collector.pritnerUVTemp, sl.UVLedTemp)
+		optional(collector.printerResinLevel, sl.ResinLevel)
+		optional(collector.printerResinRemaining, sl.ResinRemaining)
+		optional(collector.printerResinUsed, sl.ResinUsed)
+		flag(collector.printerResinLow, sl.ResinLow)
+		flag(collector.printerTankFull, sl.TankFull)
+		optional(collector.printerTiltTime, sl.TiltTimeFast, "fast")
+		optional(collector.printerTiltTime, sl.TiltTimeSlow, "slow")
+		optional(collector.printerUVLedUsage, sl.UVLedUsage)
+
+		if layers := sl.Layers; layers != nil {
+			gauge(collector.printerLayerCurrent, layers.Current)
+			gauge(collector.printerLayers, layers.Total)
+			gauge(collector.printerExposureTime, layers.ExposureTime, "layer")
+			gauge(collector.printerExposureTime, layers.ExposureTimeFirst, "first")
+			gauge(collector.printerExposureTime, layers.ExposureTimeCalibration, "calibration")
+		}
+	}
 
-			printerToolTempTarget := prometheus.MustNewConstMetric(collector.printerToolTempTarget, prometheus.GaugeValue,
-				printer.Temperature.Tool0.Target, GetLabels(s, job, "0")...)
+	for _, v := range snapshot.FileCounts {
+		gauge(collector.printerFiles, v.Files, v.Storage)
+	}
 
-			ch <- printerToolTempTarget
+	for _, v := range snapshot.Storage {
+		gauge(collector.printerStorageAvailable, BoolToFloat(v.Available), v.Name, v.Type)
+		gauge(collector.printerStorageReadOnly, BoolToFloat(v.ReadOnly), v.Name, v.Type)
 
-			printerToolTempOffset := prometheus.MustNewConstMetric(collector.printerToolTempOffset, prometheus.GaugeValue,
-				printer.Temperature.Tool0.Offset, GetLabels(s, job, "0")...)
+		if !v.Available {
+			continue // missing storage has no space and files
+		}
 
-			ch <- printerToolTempOffset
+		optional(collector.printerStorageFree, v.Free, v.Name, v.Type)
+		optional(collector.printerStorageTotal, v.Total, v.Name, v.Type)
+		optional(collector.printerStorageFilesSize, v.PrintFiles, v.Name, v.Type, "print")
+		optional(collector.printerStorageFilesSize, v.SystemFiles, v.Name, v.Type, "system")
+		optional(collector.printerStorageFiles, v.Files, v.Name, v.Type)
+	}
 
-			printerToolTemp := prometheus.MustNewConstMetric(collector.printerToolTemp, prometheus.GaugeValue,
-				printer.Temperature.Tool0.Actual, GetLabels(s, job, "0")...)
+	for _, file := range snapshot.Files {
+		gauge(collector.printerFileSize, file.Size, file.Storage, file.Path, file.Name)
+		gauge(collector.printerFileModified, file.Modified, file.Storage, file.Path, file.Name)
+	}
 
-			ch <- printerToolTemp
+	for _, camera := range snapshot.Cameras {
+		gauge(collector.printerCameras, BoolToFloat(camera.Connected), camera.ID, camera.Name, camera.Resolution)
+	}
 
-			printerUp = prometheus.MustNewConstMetric(collector.printerUp, prometheus.GaugeValue,
-				1, s.Address, s.Type, s.Name)
+	for _, camera := range snapshot.CameraSnapshots {
+		ch <- prometheus.MustNewConstMetric(collector.printerCameraFailures, prometheus.CounterValue,
+			camera.Failures, labels(camera.CameraID, camera.CameraName)...)
 
-			ch <- printerUp
+		if camera.Time.IsZero() {
+			continue
+		}
 
-			log.Debug().Msg("Scraping done at " + s.Address)
-		}(s)
+		gauge(collector.printerCameraSnapshotAge, snapshot.Time.Sub(camera.Time).Seconds(), camera.CameraID, camera.CameraName)
+		gauge(collector.printerCameraSnapshotSize, float64(camera.Size), camera.CameraID, camera.CameraName)
 	}
-	wg.Wait()
+
+	ch <- prometheus.MustNewConstMetric(collector.printerUp, prometheus.GaugeValue, 1, getPrinterLabels(s)...)
 }
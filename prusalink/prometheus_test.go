@@ -0,0 +1,340 @@
+package prusalink
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/expfmt"
+	"github.com/pstrobl96/prusa_exporter/config"
+)
+
+var (
+	update = flag.Bool("update", false, "update golden files in testdata/golden")
+
+	// goldenModels are printers with recorded API responses in testdata/<model>, suffix after _ describes variant of the model
+	goldenModels = []string{"MINI", "MK4", "MK4_MMU3", "XL", "I3MK3S", "SL1", "SL1S", "OCTOPRINT", "MOONRAKER"}
+
+	fqNameRegexp = regexp.MustCompile(`fqName: "([^"]+)"`)
+
+	variableLabelsRegexp = regexp.MustCompile(`variableLabels: \{([^}]*)\}`)
+)
+
+// newRecordedPrinter returns a server that answers PrusaLink API requests with responses recorded in testdata/<model>.
+// Path /api/v1/status is served from file v1_status.json and /printer/info of Moonraker from printer_info.json,
+// endpoints without recorded response return 404.
+func newRecordedPrinter(model string) *httptest.Server {
+	dir := filepath.Join("testdata", model)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.ReplaceAll(strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api"), "/"), "/", "_") + ".json"
+		body, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+}
+
+// fixNow fixes time of snapshots for the test, so metrics derived from time like ETA of jobs are stable
+func fixNow(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+}
+
+// newGoldenCollector returns a collector scraping the given recorded printers
+func newGoldenCollector(t *testing.T, models ...string) (*Collector, map[string]string) {
+	t.Helper()
+
+	fixNow(t)
+	var cfg config.Config
+	cfg.Exporter.ScrapeTimeout = 1000
+	addresses := map[string]string{}
+
+	for _, model := range models {
+		server := newRecordedPrinter(model)
+		t.Cleanup(server.Close)
+
+		address, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cfg.Printers = append(cfg.Printers, config.Printers{Address: address.Host, Name: "golden", Type: strings.Split(model, "_")[0]})
+		addresses[address.Host] = strings.ToLower(model) + ".local"
+	}
+
+	return NewCollector(cfg), addresses
+}
+
+// gatherText returns metrics of the collector in text exposition format with replaced addresses
+func gatherText(t *testing.T, collector prometheus.Collector, replace map[string]string) string {
+	t.Helper()
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(collector)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToText(&buffer, family); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	text := buffer.String()
+	for from, to := range replace {
+		text = strings.ReplaceAll(text, from, to)
+	}
+
+	return text
+}
+
+// compareGolden compares got with the golden file, golden file is rewritten when -update flag is set
+func compareGolden(t *testing.T, path string, got string) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(want) != got {
+		t.Errorf("output differs from %s, run go test ./... -update to accept the change\n--- want\n%s\n--- got\n%s", path, want, got)
+	}
+}
+
+func TestCollectGolden(t *testing.T) {
+	for _, model := range goldenModels {
+		t.Run(model, func(t *testing.T) {
+			collector, addresses := newGoldenCollector(t, model)
+			compareGolden(t, filepath.Join("testdata", "golden", model+".prom"), gatherText(t, collector, addresses))
+		})
+	}
+}
+
+func TestDescriptorsUnique(t *testing.T) {
+	collector := NewCollector(config.Config{})
+	descriptors := make(chan *prometheus.Desc)
+
+	go func() {
+		collector.Describe(descriptors)
+		close(descriptors)
+	}()
+
+	seen := map[string]bool{}
+	for desc := range descriptors {
+		match := fqNameRegexp.FindStringSubmatch(desc.String())
+		if match == nil {
+			t.Fatalf("descriptor without name %s", desc)
+		}
+		if seen[match[1]] {
+			t.Errorf("metric %s is described more than once", match[1])
+		}
+		seen[match[1]] = true
+	}
+}
+
+func TestLabelsBuiltin(t *testing.T) {
+	var cfg config.Config
+	cfg.Exporter.Prusalink.JobLabels = "all"
+	descriptors := make(chan *prometheus.Desc)
+
+	go func() {
+		NewCollector(cfg).Describe(descriptors)
+		NewFleetCollector().Describe(descriptors)
+		close(descriptors)
+	}()
+
+	for desc := range descriptors {
+		match := variableLabelsRegexp.FindStringSubmatch(desc.String())
+		if match == nil || match[1] == "" {
+			continue
+		}
+		for _, label := range strings.Split(match[1], ",") {
+			if !config.IsBuiltinLabel(label) {
+				t.Errorf("label %s of %s is not a builtin label, custom labels could collide with it", label, desc)
+			}
+		}
+	}
+}
+
+func TestCustomLabels(t *testing.T) {
+	for _, mode := range []string{"info", "all"} {
+		t.Run(mode, func(t *testing.T) {
+			_, addresses := newGoldenCollector(t, "MK4", "MINI")
+			configuration.Exporter.Prusalink.JobLabels = mode
+			configuration.Groups = map[string]config.Group{"farm": {Labels: map[string]string{"site": "prague", "room": "hall"}}}
+			configuration.Printers[0].Group = "farm"
+			configuration.Printers[0].Labels = map[string]string{"room": "lab"}
+			text := gatherText(t, NewCollector(configuration), addresses)
+
+			for _, want := range []string{
+				`prusa_up{group="farm",printer_address="mk4.local",printer_model="MK4",printer_name="golden",room="lab",site="prague"} 1`,
+				`prusa_up{group="",printer_address="mini.local",printer_model="MINI",printer_name="golden",room="",site=""} 1`,
+			} {
+				if !strings.Contains(text, want) {
+					t.Errorf("metrics do not contain %s", want)
+				}
+			}
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	collector, _ := newGoldenCollector(t, goldenModels...)
+
+	problems, err := testutil.CollectAndLint(collector)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	for _, problem := range problems {
+		fmt.Fprintf(&buffer, "%s: %s\n", problem.Metric, problem.Text)
+	}
+
+	compareGolden(t, filepath.Join("testdata", "golden", "lint.txt"), buffer.String())
+}
+
+func TestFileInventory(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		want  map[string]int // printer model -> number of files in inventory
+	}{
+		{"default limit", 0, map[string]int{"mk4.local": 1, "i3mk3s.local": 86}},
+		{"limited", 5, map[string]int{"mk4.local": 1, "i3mk3s.local": 5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			collector, addresses := newGoldenCollector(t, "MK4", "I3MK3S")
+			configuration.Exporter.Prusalink.Files.Inventory = true
+			configuration.Exporter.Prusalink.Files.Limit = test.limit
+
+			sizes, modified := map[string]int{}, map[string]int{}
+			for _, line := range strings.Split(gatherText(t, collector, addresses), "\n") {
+				for address := range test.want {
+					if !strings.Contains(line, `printer_address="`+address+`"`) {
+						continue
+					}
+					if strings.HasPrefix(line, "prusa_file_size_bytes{") {
+						sizes[address]++
+					}
+					if strings.HasPrefix(line, "prusa_file_modified_timestamp_seconds{") {
+						modified[address]++
+					}
+				}
+			}
+
+			for address, want := range test.want {
+				if sizes[address] != want {
+					t.Errorf("%s has %d files in inventory, want %d", address, sizes[address], want)
+				}
+				if modified[address] != sizes[address] {
+					t.Errorf("%s has %d modification times for %d files", address, modified[address], sizes[address])
+				}
+			}
+		})
+	}
+}
+
+func TestJobLabels(t *testing.T) {
+	tests := []struct {
+		mode     string
+		labelled bool // metrics other than prusa_job_info have job labels
+		jobInfo  bool
+	}{
+		{mode: "", jobInfo: true},
+		{mode: "info", jobInfo: true},
+		{mode: "all", labelled: true},
+		{mode: "off"},
+		{mode: "unknown", jobInfo: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.mode, func(t *testing.T) {
+			_, addresses := newGoldenCollector(t, "MK4")
+			configuration.Exporter.Prusalink.JobLabels = test.mode
+			collector := NewCollector(configuration)
+
+			labelled, jobInfo := false, false
+			for _, line := range strings.Split(gatherText(t, collector, addresses), "\n") {
+				if strings.HasPrefix(line, "prusa_job_info{") {
+					jobInfo = true
+				} else if strings.HasPrefix(line, "prusa_") && strings.Contains(line, "printer_job_name=") {
+					labelled = true
+				}
+			}
+
+			if labelled != test.labelled {
+				t.Errorf("metrics have job labels %v, want %v", labelled, test.labelled)
+			}
+			if jobInfo != test.jobInfo {
+				t.Errorf("prusa_job_info is returned %v, want %v", jobInfo, test.jobInfo)
+			}
+		})
+	}
+}
+
+func TestMetricNames(t *testing.T) {
+	tests := []struct {
+		mode   string
+		legacy bool
+		v2     bool
+	}{
+		{mode: "", legacy: true, v2: true},
+		{mode: "both", legacy: true, v2: true},
+		{mode: "legacy", legacy: true},
+		{mode: "v2", v2: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.mode, func(t *testing.T) {
+			_, addresses := newGoldenCollector(t, "SL1")
+			configuration.Exporter.MetricNames = test.mode
+			text := gatherText(t, NewCollector(configuration), addresses)
+
+			if legacy := strings.Contains(text, "\nprusa_bed_temp{"); legacy != test.legacy {
+				t.Errorf("legacy prusa_bed_temp is returned %v, want %v", legacy, test.legacy)
+			}
+			if v2 := strings.Contains(text, "\nprusa_bed_temperature_celsius{"); v2 != test.v2 {
+				t.Errorf("v2 prusa_bed_temperature_celsius is returned %v, want %v", v2, test.v2)
+			}
+			if !strings.Contains(text, "\nprusa_up{") {
+				t.Error("metric without v2 name is not returned")
+			}
+
+			// resin level is in percents, v2 name is ratio
+			if test.v2 && !strings.Contains(text, `prusa_resin_level_ratio{printer_address="sl1.local",printer_model="SL1",printer_name="golden"} 0.`) {
+				t.Error("prusa_resin_level_ratio is not scaled to ratio")
+			}
+		})
+	}
+}
@@ -1,9 +1,15 @@
 package prusalink
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/icholy/digest"
@@ -13,24 +19,29 @@ import (
 
 var (
 	printerBoards = map[string]string{
-		"MINI":    "buddy",
-		"MK35":    "buddy",
-		"MK39":    "buddy",
-		"MK4":     "buddy",
-		"XL":      "buddy",
-		"IX":      "buddy",
-		"I3MK3S":  "einsy",
-		"I3MK3":   "einsy",
-		"I3MK25S": "einsy",
-		"I3MK25":  "einsy",
-		"SL1":     "sl",
-		"SL1S":    "sl",
-	}
-
-	// used for autodetection - does not work with changed hostname :sad:
+		"MINI":     "buddy",
+		"MINIPLUS": "buddy",
+		"MK35":     "buddy",
+		"MK39":     "buddy",
+		"MK4":      "buddy",
+		"XL":       "buddy",
+		"COREONE":  "buddy",
+		"IX":       "buddy",
+		"I3MK3S":   "einsy",
+		"I3MK3":    "einsy",
+		"I3MK25S":  "einsy",
+		"I3MK25":   "einsy",
+		"SL1":      "sl",
+		"SL1S":     "sl",
+	}
+
+	// used for autodetection by hostname, see detect.go for other signals used when hostname is changed or shared
 	printerTypes = map[string]string{
 		"PrusaMINI":         "MINI",
-		"PrusaMK4":          "MK4", // unfortunately MK3.5 is also detected as MK4
+		"PrusaMK4":          "MK4", // MK3.5, MK3.9 and Core One are detected as MK4 until refined by detectPrinter
+		"PrusaMK3.5":        "MK35",
+		"PrusaMK3.9":        "MK39",
+		"PrusaCOREONE":      "COREONE",
 		"PrusaXL":           "XL",
 		"PrusaLink I3MK3S":  "I3MK3S",
 		"PrusaLink I3MK3":   "I3MK3",
@@ -41,15 +52,101 @@ var (
 		"Prusa_iX":          "IX", // can be found in src/common/config.h in firmware source code
 	}
 
+	// printerSources read printers without PrusaLink, source is selected by type of the printer in configuration.
+	// Printers with other types are read by prusaLinkSource.
+	printerSources = map[string]func(config.Printers) Source{
+		"OCTOPRINT": func(printer config.Printers) Source { return octoPrintSource{printer: printer} },
+		"MOONRAKER": func(printer config.Printers) Source { return moonrakerSource{printer: printer} },
+	}
+
+	// printers with more tools, their /api/v1/status reports every tool as a slot
+	toolchangers = map[string]bool{
+		"XL": true,
+	}
+
+	// jobLabelModes are allowed values of job_labels - job labels on prusa_job_info only, on all metrics or nowhere
+	jobLabelModes = map[string]bool{
+		"info": true,
+		"all":  true,
+		"off":  true,
+	}
+
+	// defaultJobLabels is mode of job labels when it is not configured, series of other metrics do not change with every print
+	defaultJobLabels = "info"
+
+	// defaultFileLimit is maximum number of files in the file inventory of one printer when limit is not configured
+	defaultFileLimit = 100
+
+	// customLabels are sorted names of custom labels of configured printers, they follow printer_name in labels of metrics
+	customLabels []string
+
+	// printersMutex guards configuration.Printers, discovered printers are added while printers are scraped
+	printersMutex sync.Mutex
+
 	configuration config.Config
 )
 
+// GetPrinters returns copy of printers scraped by the exporter - configured and added by discovery
+func GetPrinters() []config.Printers {
+	printersMutex.Lock()
+	defer printersMutex.Unlock()
+
+	return slices.Clone(configuration.Printers)
+}
+
+// AddPrinter adds the printer to scraped printers, it returns false when a printer with the same address is already scraped
+func AddPrinter(printer config.Printers) bool {
+	printersMutex.Lock()
+	defer printersMutex.Unlock()
+
+	for _, scraped := range configuration.Printers {
+		if scraped.Address == printer.Address {
+			return false
+		}
+	}
+	configuration.Printers = append(configuration.Printers, printer)
+	log.Info().Msg("Printer at " + printer.Address + " added")
+	return true
+}
+
 // GetLabels is used to get the labels for the given printer and job
 func GetLabels(printer config.Printers, job Job, labelValues ...string) []string {
 	if job == (Job{}) {
-		return append([]string{printer.Address, printer.Type, printer.Name, "", ""}, labelValues...)
+		return getPrinterLabels(printer, append([]string{"", ""}, labelValues...)...)
+	}
+	return getPrinterLabels(printer, append([]string{job.Job.File.Name, job.Job.File.Path}, labelValues...)...)
+}
+
+// getPrinterLabels returns address, model and name of the printer followed by values of custom labels and labelValues,
+// custom label that the printer does not have is empty
+func getPrinterLabels(printer config.Printers, labelValues ...string) []string {
+	values := []string{printer.Address, printer.Type, printer.Name}
+	labels := configuration.GetPrinterLabels(printer)
+	for _, name := range customLabels {
+		values = append(values, labels[name])
+	}
+	return append(values, labelValues...)
+}
+
+// GetToolLabel returns tool label for the slot number from /api/v1/status. Slots are numbered from 1,
+// tools are numbered from 0 as in tool0 of /api/printer and n of syslog dwarf metrics.
+func GetToolLabel(slot string) string {
+	number, err := strconv.Atoi(slot)
+	if err != nil {
+		return slot
 	}
-	return append([]string{printer.Address, printer.Type, printer.Name, job.Job.File.Name, job.Job.File.Path}, labelValues...)
+	return strconv.Itoa(number - 1)
+}
+
+// GetStorageName returns name of the storage from /api/files - display name with fallback to name and origin for firmware without it
+func GetStorageName(storage File) string {
+	if storage.Display != "" {
+		return storage.Display
+	}
+	if storage.Name != "" {
+		return storage.Name
+	}
+	return storage.Origin
 }
 
 // BoolToFloat is used for basic parsing boolean to float64
@@ -64,13 +161,10 @@ func BoolToFloat(boolean bool) float64 {
 
 // getStateFlag returns the state flag for the given printer.
 // The state flag is a float64 value representing the current state of the printer.
-// It is used for tracking the printer's status and progress.
+// It is used for tracking the printer's status and progress. Flags of the job are checked before operational,
+// as OctoPrint reports operational printer also while it prints.
 func getStateFlag(printer Printer) float64 {
-	if printer.State.Flags.Operational {
-		return 1
-	} else if printer.State.Flags.Prepared {
-		return 2
-	} else if printer.State.Flags.Paused {
+	if printer.State.Flags.Paused {
 		return 3
 	} else if printer.State.Flags.Printing {
 		return 4
@@ -80,6 +174,10 @@ func getStateFlag(printer Printer) float64 {
 		return 6
 	} else if printer.State.Flags.Error {
 		return 7
+	} else if printer.State.Flags.Operational {
+		return 1
+	} else if printer.State.Flags.Prepared {
+		return 2
 	} else if printer.State.Flags.SdReady {
 		return 8
 	} else if printer.State.Flags.ClosedOrError || printer.State.Flags.ClosedOnError {
@@ -95,44 +193,70 @@ func getStateFlag(printer Printer) float64 {
 	}
 }
 
-// accessPrinterEndpoint is used to access the printer's API endpoint
-func accessPrinterEndpoint(path string, printer config.Printers) ([]byte, error) {
-	url := string("http://" + printer.Address + "/api/" + path)
-	var (
-		res    *http.Response
-		result []byte
-		err    error
-	)
+// isPrinting checks if the printer of the snapshot prints, paused printer does not
+func isPrinting(snapshot PrinterSnapshot) bool {
+	return snapshot.StateCode == 4
+}
 
-	if printer.Apikey == "" {
-		client := &http.Client{
-			Transport: &digest.Transport{
-				Username: printer.Username,
-				Password: printer.Password,
-			},
-			Timeout: time.Duration(configuration.Exporter.ScrapeTimeout) * time.Millisecond,
-		}
-		res, err = client.Get(url)
+// isJobActive checks if the printer of the snapshot has a job - it prints, is paused, pausing or cancelling
+func isJobActive(snapshot PrinterSnapshot) bool {
+	return snapshot.StateCode >= 3 && snapshot.StateCode <= 6
+}
 
-		if err != nil {
-			return result, err
-		}
-	} else {
-		req, err := http.NewRequest("GET", url, nil)
-		client := &http.Client{
-			Timeout: time.Duration(configuration.Exporter.ScrapeTimeout) * time.Millisecond,
-		}
+// isFinished checks if the printer of the snapshot finished the job, finished printer is also operational,
+// so prusa_status does not tell it
+func isFinished(snapshot PrinterSnapshot) bool {
+	return snapshot.State == "Finished"
+}
 
-		if err != nil {
-			return result, err
-		}
+// isPaused checks if the printer of the snapshot is paused or pausing
+func isPaused(snapshot PrinterSnapshot) bool {
+	return snapshot.StateCode == 3 || snapshot.StateCode == 6
+}
 
-		req.Header.Add("X-Api-Key", printer.Apikey)
-		res, err = client.Do(req)
-		if err != nil {
-			return result, err
-		}
+// isBusy checks if the printer of the snapshot is cancelling or busy with something else than printing
+func isBusy(snapshot PrinterSnapshot) bool {
+	return snapshot.StateCode == 5 || snapshot.StateCode == 11
+}
+
+// isFailed checks if the printer of the snapshot is in error
+func isFailed(snapshot PrinterSnapshot) bool {
+	return snapshot.StateCode == 7 || snapshot.StateCode == 9
+}
+
+// getStatePrinter returns printer with state text and flags of /api/printer for the state of /api/v1/status - e.g. PRINTING.
+// It is used for printers without /api/printer, so all printers have the same prusa_status.
+func getStatePrinter(state string) Printer {
+	var printer Printer
+	flags := &printer.State.Flags
+
+	switch state {
+	case "PRINTING":
+		printer.State.Text, flags.Printing = "Printing", true
+	case "PAUSED":
+		printer.State.Text, flags.Paused = "Paused", true
+	case "ERROR":
+		printer.State.Text, flags.Error = "Error", true
+	case "FINISHED":
+		printer.State.Text, flags.Operational, flags.Finished = "Finished", true, true
+	case "ATTENTION", "BUSY":
+		printer.State.Text, flags.Busy = "Busy", true
+	default:
+		printer.State.Text, flags.Operational, flags.Ready = "Operational", true, true
 	}
+
+	return printer
+}
+
+// accessPrinterEndpoint is used to access the printer's API endpoint
+func accessPrinterEndpoint(ctx context.Context, path string, printer config.Printers) ([]byte, error) {
+	var result []byte
+
+	res, err := getPrinterResponse(ctx, "/api/"+path, printer)
+	if err != nil {
+		return result, err
+	}
+
 	result, err = io.ReadAll(res.Body)
 	res.Body.Close()
 
@@ -143,10 +267,49 @@ func accessPrinterEndpoint(path string, printer config.Printers) ([]byte, error)
 	return result, nil
 }
 
+// accessPrinterPath is used to access the path of the printer outside of /api/, unlike accessPrinterEndpoint it fails when status is not 200
+func accessPrinterPath(ctx context.Context, path string, printer config.Printers) ([]byte, error) {
+	res, err := getPrinterResponse(ctx, path, printer)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New(path + " returned " + res.Status)
+	}
+
+	return io.ReadAll(res.Body)
+}
+
+// getPrinterResponse sends authenticated GET request for the path of the printer, path starts with / - e.g. /thumb/l/usb/BENCHY~1.BGC
+func getPrinterResponse(ctx context.Context, path string, printer config.Printers) (*http.Response, error) {
+	url := string("http://" + printer.Address + path)
+	client := &http.Client{
+		Timeout: time.Duration(configuration.Exporter.ScrapeTimeout) * time.Millisecond,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if printer.Apikey == "" {
+		client.Transport = &digest.Transport{
+			Username: printer.Username,
+			Password: printer.Password,
+		}
+	} else {
+		req.Header.Add("X-Api-Key", printer.Apikey)
+	}
+
+	return client.Do(req)
+}
+
 // GetVersion is used to get the printer's version API endpoint
-func GetVersion(printer config.Printers) (Version, error) {
+func GetVersion(ctx context.Context, printer config.Printers) (Version, error) {
 	var version Version
-	response, err := accessPrinterEndpoint("version", printer)
+	response, err := accessPrinterEndpoint(ctx, "version", printer)
 
 	if err != nil {
 		return version, err
@@ -158,9 +321,9 @@ func GetVersion(printer config.Printers) (Version, error) {
 }
 
 // GetJob is used to get the printer's job API endpoint
-func GetJob(printer config.Printers) (Job, error) {
+func GetJob(ctx context.Context, printer config.Printers) (Job, error) {
 	var job Job
-	response, err := accessPrinterEndpoint("job", printer)
+	response, err := accessPrinterEndpoint(ctx, "job", printer)
 
 	if err != nil {
 		return job, err
@@ -172,9 +335,9 @@ func GetJob(printer config.Printers) (Job, error) {
 }
 
 // GetPrinter is used to get the printer's printer API endpoint
-func GetPrinter(printer config.Printers) (Printer, error) {
+func GetPrinter(ctx context.Context, printer config.Printers) (Printer, error) {
 	var printerData Printer
-	response, err := accessPrinterEndpoint("printer", printer)
+	response, err := accessPrinterEndpoint(ctx, "printer", printer)
 
 	if err != nil {
 		return printerData, err
@@ -185,10 +348,38 @@ func GetPrinter(printer config.Printers) (Printer, error) {
 	return printerData, err
 }
 
+// GetSLPrinter is used to get SL specific data from the printer's printer API endpoint
+func GetSLPrinter(ctx context.Context, printer config.Printers) (SLPrinter, error) {
+	var printerData SLPrinter
+	response, err := accessPrinterEndpoint(ctx, "printer", printer)
+
+	if err != nil {
+		return printerData, err
+	}
+
+	err = json.Unmarshal(response, &printerData)
+
+	return printerData, err
+}
+
+// GetSLJob is used to get SL specific data from the printer's job API endpoint
+func GetSLJob(ctx context.Context, printer config.Printers) (SLJob, error) {
+	var job SLJob
+	response, err := accessPrinterEndpoint(ctx, "job", printer)
+
+	if err != nil {
+		return job, err
+	}
+
+	err = json.Unmarshal(response, &job)
+
+	return job, err
+}
+
 // GetFiles is used to get the printer's files API endpoint
-func GetFiles(printer config.Printers) (Files, error) {
+func GetFiles(ctx context.Context, printer config.Printers) (Files, error) {
 	var files Files
-	response, err := accessPrinterEndpoint("files?recursive=true", printer)
+	response, err := accessPrinterEndpoint(ctx, "files?recursive=true", printer)
 
 	if err != nil {
 		return files, err
@@ -200,9 +391,9 @@ func GetFiles(printer config.Printers) (Files, error) {
 }
 
 // GetJobV1 is used to get the printer's job v1 API endpoint
-func GetJobV1(printer config.Printers) (JobV1, error) {
+func GetJobV1(ctx context.Context, printer config.Printers) (JobV1, error) {
 	var job JobV1
-	response, err := accessPrinterEndpoint("v1/job", printer)
+	response, err := accessPrinterEndpoint(ctx, "v1/job", printer)
 
 	if err != nil {
 		return job, err
@@ -214,9 +405,9 @@ func GetJobV1(printer config.Printers) (JobV1, error) {
 }
 
 // GetStatus is used to get Buddy status endpoint
-func GetStatus(printer config.Printers) (Status, error) {
+func GetStatus(ctx context.Context, printer config.Printers) (Status, error) {
 	var status Status
-	response, err := accessPrinterEndpoint("v1/status", printer)
+	response, err := accessPrinterEndpoint(ctx, "v1/status", printer)
 
 	if err != nil {
 		return status, err
@@ -228,9 +419,9 @@ func GetStatus(printer config.Printers) (Status, error) {
 }
 
 // GetStorageV1 is used to get the printer's storage v1 API endpoint
-func GetStorageV1(printer config.Printers) (StorageV1, error) {
+func GetStorageV1(ctx context.Context, printer config.Printers) (StorageV1, error) {
 	var storage StorageV1
-	response, err := accessPrinterEndpoint("v1/storage", printer)
+	response, err := accessPrinterEndpoint(ctx, "v1/storage", printer)
 
 	if err != nil {
 		return storage, err
@@ -241,10 +432,24 @@ func GetStorageV1(printer config.Printers) (StorageV1, error) {
 	return storage, err
 }
 
+// GetFilesV1 is used to get files of the storage from the printer's v1 files API endpoint, storage is path from StorageV1 - e.g. /usb/
+func GetFilesV1(ctx context.Context, printer config.Printers, storage string) (FilesV1, error) {
+	var files FilesV1
+	response, err := accessPrinterEndpoint(ctx, "v1/files/"+strings.Trim(storage, "/"), printer)
+
+	if err != nil {
+		return files, err
+	}
+
+	err = json.Unmarshal(response, &files)
+
+	return files, err
+}
+
 // GetInfo is used to get the printer's info API endpoint
-func GetInfo(printer config.Printers) (Info, error) {
+func GetInfo(ctx context.Context, printer config.Printers) (Info, error) {
 	var info Info
-	response, err := accessPrinterEndpoint("v1/info", printer)
+	response, err := accessPrinterEndpoint(ctx, "v1/info", printer)
 
 	if err != nil {
 		return info, err
@@ -256,9 +461,9 @@ func GetInfo(printer config.Printers) (Info, error) {
 }
 
 // GetSettings is used to get the printer's settings API endpoint
-func GetSettings(printer config.Printers) (Settings, error) {
+func GetSettings(ctx context.Context, printer config.Printers) (Settings, error) {
 	var settings Settings
-	response, err := accessPrinterEndpoint("settings", printer)
+	response, err := accessPrinterEndpoint(ctx, "settings", printer)
 
 	if err != nil {
 		return settings, err
@@ -270,9 +475,9 @@ func GetSettings(printer config.Printers) (Settings, error) {
 }
 
 // GetCameras is used to get the printer's cameras API endpoint
-func GetCameras(printer config.Printers) (Cameras, error) {
+func GetCameras(ctx context.Context, printer config.Printers) (Cameras, error) {
 	var cameras Cameras
-	response, err := accessPrinterEndpoint("v1/cameras", printer)
+	response, err := accessPrinterEndpoint(ctx, "v1/cameras", printer)
 
 	if err != nil {
 		return cameras, err
@@ -284,9 +489,9 @@ func GetCameras(printer config.Printers) (Cameras, error) {
 }
 
 // GetPrinterProfiles is used to get the printer's printerprofiles API endpoint
-func GetPrinterProfiles(printer config.Printers) (PrinterProfiles, error) {
+func GetPrinterProfiles(ctx context.Context, printer config.Printers) (PrinterProfiles, error) {
 	var profiles PrinterProfiles
-	response, err := accessPrinterEndpoint("v1/printerprofiles", printer)
+	response, err := accessPrinterEndpoint(ctx, "printerprofiles", printer)
 
 	if err != nil {
 		return profiles, err
@@ -297,61 +502,102 @@ func GetPrinterProfiles(printer config.Printers) (PrinterProfiles, error) {
 	return profiles, err
 }
 
-// GetPrinterType returns the printer type of the given printer - e.g. "MINI", "MK4", "XL", "I3MK3S", "I3MK3", "I3MK25S",
-func GetPrinterType(printer config.Printers) (string, error) {
-	version, err := GetVersion(printer)
+// GetMoonrakerStatus is used to get Klipper objects from Moonraker
+func GetMoonrakerStatus(ctx context.Context, printer config.Printers) (MoonrakerStatus, error) {
+	var status MoonrakerStatus
+	response, err := accessPrinterPath(ctx, "/printer/objects/query?extruder&heater_bed&print_stats&display_status&toolhead&fan&gcode_move", printer)
+
 	if err != nil {
-		return "unknown", err
+		return status, err
 	}
 
-	printerType := version.Hostname
+	err = json.Unmarshal(response, &status)
 
-	if version.Hostname == "" {
-		if version.Original == "" {
-			info, err := GetInfo(printer)
-			if err != nil {
-				return "unknown", err
-			}
-			printerType = info.Hostname
-		} else {
-			printerType = version.Original
-		}
-	} else if version.Original != "" {
-		printerType = version.Original
+	return status, err
+}
+
+// GetMoonrakerInfo is used to get info about Klipper from Moonraker
+func GetMoonrakerInfo(ctx context.Context, printer config.Printers) (MoonrakerInfo, error) {
+	var info MoonrakerInfo
+	response, err := accessPrinterPath(ctx, "/printer/info", printer)
+
+	if err != nil {
+		return info, err
 	}
 
-	if printerTypes[printerType] != "" {
-		printerType = printerTypes[printerType]
+	err = json.Unmarshal(response, &info)
+
+	return info, err
+}
+
+// GetMoonrakerServer is used to get info about Moonraker itself
+func GetMoonrakerServer(ctx context.Context, printer config.Printers) (MoonrakerServer, error) {
+	var server MoonrakerServer
+	response, err := accessPrinterPath(ctx, "/server/info", printer)
+
+	if err != nil {
+		return server, err
 	}
 
-	if printerType == "" {
-		printerType = "unknown"
+	err = json.Unmarshal(response, &server)
+
+	return server, err
+}
+
+// GetPrinterType returns the printer type of the given printer - e.g. "MINI", "MK4", "MK35", "COREONE", "XL", "I3MK3S", "SL1S"
+func GetPrinterType(ctx context.Context, printer config.Printers) (string, error) {
+	detection, err := GetDetection(ctx, printer)
+	if err != nil {
+		return "unknown", err
 	}
 
-	log.Trace().Msg(printerType + " detected for " + printer.Address + " (" + printer.Name + ")")
+	log.Trace().Msg(detection.Type + " detected for " + printer.Address + " (" + printer.Name + ")")
 
-	return printerType, nil
+	return detection.Type, nil
 }
 
-// ProbePrinter is used to probe the printer - just testing the connection
+// prusaLinkRealm is realm of authentication challenge of PrusaLink, both in firmware of the printer and on Raspberry Pi
+const prusaLinkRealm = `realm="Printer API"`
+
+// isPrusaLink returns true when the response to request without credentials comes from PrusaLink
+func isPrusaLink(r *http.Response, version Version) bool {
+	return strings.Contains(r.Header.Get("WWW-Authenticate"), prusaLinkRealm) || strings.Contains(r.Header.Get("Server"), "PrusaLink") ||
+		strings.Contains(version.Text, "PrusaLink")
+}
+
+// ProbePrinter is used to probe the printer - just testing the connection. API key is sent only when the host answers
+// as PrusaLink without it, so the key does not leak to other devices found by discovery.
 func ProbePrinter(printer config.Printers) (bool, error) {
-	req, _ := http.NewRequest("GET", "http://"+printer.Address+"/", nil)
 	client := &http.Client{Timeout: time.Duration(configuration.Exporter.ScrapeTimeout) * time.Millisecond}
-	r, e := client.Do(req)
-
+	r, e := client.Get("http://" + printer.Address + "/api/version")
 	if e != nil {
 		return false, e
 	}
 
-	if r.StatusCode == 401 {
-		log.Debug().Msg("401 Unauthorized, trying to access with API key - " + printer.Address)
-		req, _ := http.NewRequest("GET", "http://"+printer.Address+"/api/v1/status", nil)
-		req.Header.Add("X-Api-Key", printer.Apikey)
-		r, e = client.Do(req)
-		if e != nil {
-			return false, e
-		}
+	var version Version
+	if r.StatusCode == 200 {
+		json.NewDecoder(r.Body).Decode(&version) // printer without authentication tells its name in version
+	}
+	io.Copy(io.Discard, r.Body)
+	r.Body.Close()
+
+	if !isPrusaLink(r, version) {
+		log.Debug().Msg("Device is not PrusaLink, credentials are not sent - " + printer.Address)
+		return false, nil
+	}
+	if r.StatusCode != 401 {
+		return r.StatusCode == 200, nil
+	}
+
+	log.Debug().Msg("401 Unauthorized, trying to access with API key - " + printer.Address)
+	req, _ := http.NewRequest("GET", "http://"+printer.Address+"/api/v1/status", nil)
+	req.Header.Add("X-Api-Key", printer.Apikey)
+	r, e = client.Do(req)
+	if e != nil {
+		return false, e
 	}
+	io.Copy(io.Discard, r.Body)
+	r.Body.Close()
 
 	return r.StatusCode == 200, nil
 }
@@ -0,0 +1,212 @@
+package prusalink
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+)
+
+// Source reads state of one printer from its API, e.g. PrusaLink, OctoPrint or Moonraker
+type Source interface {
+	Fetch(ctx context.Context) (PrinterSnapshot, error)
+}
+
+// PrinterSnapshot is normalised state of one printer at one moment. Sources populate it and Collector turns it into metrics,
+// so other features can reuse the same data. Optional values are pointers, nil means that the source does not know the value.
+type PrinterSnapshot struct {
+	Printer         config.Printers  `json:"-"` // printer from configuration with detected type
+	Up              bool             `json:"up"`
+	Time            time.Time        `json:"time"`
+	Info            *PrinterInfo     `json:"info,omitempty"`
+	State           string           `json:"state"`
+	StateCode       float64          `json:"state_code"` // value of prusa_status, see getStateFlag
+	Job             JobSnapshot      `json:"job"`
+	Bed             *Heater          `json:"bed,omitempty"`
+	Chamber         *Heater          `json:"chamber,omitempty"`
+	Tools           []Tool           `json:"tools,omitempty"`
+	Fans            []Fan            `json:"fans,omitempty"`
+	Axis            []Axis           `json:"axis,omitempty"`
+	NozzleDiameter  *float64         `json:"nozzle_diameter,omitempty"`
+	PrintSpeed      *float64         `json:"print_speed,omitempty"` // ratio, 1 is 100 %
+	Flow            *float64         `json:"flow,omitempty"`        // ratio, 1 is 100 %
+	Material        *Material        `json:"material,omitempty"`
+	FarmMode        *bool            `json:"farm_mode,omitempty"`
+	MMU             *bool            `json:"mmu,omitempty"`
+	MMUSlots        []MMUSlot        `json:"mmu_slots,omitempty"`
+	SL              *SLSnapshot      `json:"sl,omitempty"`
+	Storage         []Storage        `json:"storage,omitempty"`
+	FileCounts      []FileCount      `json:"file_counts,omitempty"`
+	Files           []InventoryFile  `json:"files,omitempty"`
+	Cameras         []Camera         `json:"cameras,omitempty"`
+	CameraSnapshots []CameraSnapshot `json:"camera_snapshots,omitempty"`
+	Power           *float64         `json:"power_watts,omitempty"`
+	PowerSource     string           `json:"power_source,omitempty"` // plug or syslog
+	QueuedJobs      *float64         `json:"queued_jobs,omitempty"`  // jobs waiting in queue of the printer, only Prusa Connect has queues
+}
+
+// PrinterInfo is firmware and identity of the printer returned as labels of prusa_info
+type PrinterInfo struct {
+	API      string `json:"api"`
+	Server   string `json:"server"`
+	Firmware string `json:"firmware"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Serial   string `json:"serial"`
+	Hostname string `json:"hostname"`
+}
+
+// JobSnapshot is the printed file, times are in seconds and progress is ratio (0.0 - 1.0)
+type JobSnapshot struct {
+	Name                string   `json:"name"`
+	Path                string   `json:"path"`
+	Progress            *float64 `json:"progress,omitempty"`
+	PrintTime           *float64 `json:"print_time,omitempty"`
+	TimeRemaining       *float64 `json:"time_remaining,omitempty"`
+	Material            string   `json:"material,omitempty"`             // filament type the file was sliced for
+	Filament            *float64 `json:"filament_grams,omitempty"`       // filament of the whole file in grams
+	Estimate            *float64 `json:"estimate,omitempty"`             // print time of the whole file estimated by slicer
+	InaccurateEstimates *bool    `json:"inaccurate_estimates,omitempty"` // firmware does not trust its time remaining
+}
+
+// Heater is temperature of bed, chamber or tool in Celsius
+type Heater struct {
+	Temp   float64  `json:"temp"`
+	Target float64  `json:"target"`
+	Offset *float64 `json:"offset,omitempty"`
+}
+
+// Tool is one tool of the printer, tool ID is numbered from 0
+type Tool struct {
+	Heater
+	ID             string    `json:"id"`
+	Active         *bool     `json:"active,omitempty"`
+	NozzleDiameter *float64  `json:"nozzle_diameter,omitempty"`
+	Material       *Material `json:"material,omitempty"`
+	Fans           []Fan     `json:"fans,omitempty"`
+}
+
+// Fan is speed of one fan in rpm
+type Fan struct {
+	Name  string  `json:"name"`
+	Speed float64 `json:"speed"`
+}
+
+// Axis is position of one axis in mm
+type Axis struct {
+	Name     string  `json:"name"`
+	Position float64 `json:"position"`
+}
+
+// Material is loaded filament, Loaded is false when there is no filament
+type Material struct {
+	Name   string `json:"name"`
+	Loaded bool   `json:"loaded"`
+}
+
+// MMUSlot is one slot of MMU, slot is numbered from 1
+type MMUSlot struct {
+	Slot     string   `json:"slot"`
+	Active   bool     `json:"active"`
+	Material Material `json:"material"`
+}
+
+// SLSnapshot is state of resin printer, times are in seconds
+type SLSnapshot struct {
+	Cover          bool     `json:"cover_closed"`
+	AmbientTemp    float64  `json:"ambient_temp"`
+	CPUTemp        float64  `json:"cpu_temp"`
+	UVLedTemp      float64  `json:"uv_led_temp"`
+	ResinLevel     *float64 `json:"resin_level,omitempty"`
+	ResinRemaining *float64 `json:"resin_remaining,omitempty"`
+	ResinUsed      *float64 `json:"resin_used,omitempty"`
+	ResinLow       *bool    `json:"resin_low,omitempty"`
+	TankFull       *bool    `json:"tank_full,omitempty"`
+	TiltTimeFast   *float64 `json:"tilt_time_fast,omitempty"`
+	TiltTimeSlow   *float64 `json:"tilt_time_slow,omitempty"`
+	UVLedUsage     *float64 `json:"uv_led_usage,omitempty"`
+	Layers         *Layers  `json:"layers,omitempty"`
+}
+
+// Layers is progress and exposure of the printed resin job
+type Layers struct {
+	Current                 float64 `json:"current"`
+	Total                   float64 `json:"total"`
+	ExposureTime            float64 `json:"exposure_time"`
+	ExposureTimeFirst       float64 `json:"exposure_time_first"`
+	ExposureTimeCalibration float64 `json:"exposure_time_calibration"`
+}
+
+// Storage is one storage of the printer, space is in bytes
+type Storage struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Available   bool     `json:"available"`
+	ReadOnly    bool     `json:"read_only"`
+	Free        *float64 `json:"free,omitempty"`
+	Total       *float64 `json:"total,omitempty"`
+	PrintFiles  *float64 `json:"print_files,omitempty"`  // size of print files
+	SystemFiles *float64 `json:"system_files,omitempty"` // size of system files
+	Files       *float64 `json:"files,omitempty"`        // number of files from FileCounts of the same storage
+}
+
+// FileCount is number of files in one storage
+type FileCount struct {
+	Storage string  `json:"storage"`
+	Files   float64 `json:"files"`
+}
+
+// InventoryFile is one file of the file inventory, size is in bytes and modified is unix timestamp
+type InventoryFile struct {
+	Storage  string  `json:"storage"`
+	Path     string  `json:"path"`
+	Name     string  `json:"name"`
+	Size     float64 `json:"size"`
+	Modified float64 `json:"modified"`
+}
+
+// Camera is one camera registered in PrusaLink
+type Camera struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Resolution string `json:"resolution"`
+	Connected  bool   `json:"connected"`
+}
+
+// NewSource returns source of the printer selected by its type in configuration, printers without driver are read from PrusaLink
+func NewSource(printer config.Printers) Source {
+	if source, ok := printerSources[printer.Type]; ok {
+		return source(printer)
+	}
+	return prusaLinkSource{printer: printer}
+}
+
+// now returns time of snapshots, tests replace it so metrics derived from time do not change between runs
+var now = time.Now
+
+// newSnapshot returns snapshot of the printer that is up, with state and job set
+func newSnapshot(s config.Printers, printer Printer, job Job) PrinterSnapshot {
+	snapshot := PrinterSnapshot{
+		Printer:   s,
+		Up:        true,
+		Time:      now(),
+		State:     printer.State.Text,
+		StateCode: getStateFlag(printer),
+		Job:       JobSnapshot{Name: job.Job.File.Name, Path: job.Job.File.Path},
+	}
+	if job.Job.EstimatedPrintTime > 0 {
+		snapshot.Job.Estimate = ref(job.Job.EstimatedPrintTime)
+	}
+	return snapshot
+}
+
+// isLoaded returns true when material reported by the printer is a filament, firmware reports "---" when there is none
+func isLoaded(material string) bool {
+	return material != "" && !strings.Contains(material, "-")
+}
+
+// ref returns pointer to the value for optional values of the snapshot
+func ref[T any](value T) *T {
+	return &value
+}
@@ -0,0 +1,70 @@
+package prusalink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+)
+
+func TestNewSource(t *testing.T) {
+	tests := map[string]string{
+		"MK4":       "prusalink.prusaLinkSource",
+		"":          "prusalink.prusaLinkSource",
+		"OCTOPRINT": "prusalink.octoPrintSource",
+		"MOONRAKER": "prusalink.moonrakerSource",
+	}
+
+	for printerType, want := range tests {
+		if got := fmt.Sprintf("%T", NewSource(config.Printers{Type: printerType})); got != want {
+			t.Errorf("type %q got source %s, want %s", printerType, got, want)
+		}
+	}
+}
+
+func TestFetchSnapshot(t *testing.T) {
+	server := newRecordedPrinter("XL")
+	t.Cleanup(server.Close)
+
+	address, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg config.Config
+	cfg.Exporter.ScrapeTimeout = 1000
+	NewCollector(cfg)
+
+	printer := config.Printers{Address: address.Host, Apikey: "secret", Type: "XL"}
+	snapshot, err := NewSource(printer).Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !snapshot.Up || snapshot.Info == nil || snapshot.Job.Progress == nil {
+		t.Fatalf("snapshot of printing XL is incomplete: %+v", snapshot)
+	}
+
+	if len(snapshot.Tools) < 2 || snapshot.Tools[0].ID != "0" || snapshot.Tools[0].Offset == nil {
+		t.Errorf("toolchanger tools are not sorted from tool 0 with offset: %+v", snapshot.Tools)
+	}
+
+	body, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(body), "secret") {
+		t.Error("JSON of the snapshot contains API key of the printer")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if snapshot, err := NewSource(printer).Fetch(ctx); err == nil || snapshot.Up {
+		t.Error("canceled fetch returned printer that is up")
+	}
+}
@@ -0,0 +1,383 @@
+package prusalink
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/rs/zerolog/log"
+)
+
+// prusaLinkSource reads printers from PrusaLink API, printer type is detected when it is not configured
+type prusaLinkSource struct {
+	printer config.Printers
+}
+
+// Fetch implements Source. Error of job, printer, files or version endpoint fails the whole snapshot,
+// other endpoints only leave their part of the snapshot empty.
+func (source prusaLinkSource) Fetch(ctx context.Context) (PrinterSnapshot, error) {
+	s := source.printer
+	log.Debug().Msg("Printer scraping at " + s.Address)
+
+	if s.Type == "" {
+		printerType, err := GetPrinterType(ctx, s)
+		if err != nil {
+			return PrinterSnapshot{Printer: s}, errors.New("probing printer failed - " + err.Error())
+		}
+		s.Type = printerType
+	}
+
+	job, err := GetJob(ctx, s)
+	if err != nil {
+		return PrinterSnapshot{Printer: s}, errors.New("job endpoint - " + err.Error())
+	}
+
+	printer, err := GetPrinter(ctx, s)
+	if err != nil {
+		return PrinterSnapshot{Printer: s}, errors.New("printer endpoint - " + err.Error())
+	}
+
+	files, err := GetFiles(ctx, s)
+	if err != nil {
+		return PrinterSnapshot{Printer: s}, errors.New("files endpoint - " + err.Error())
+	}
+
+	version, err := GetVersion(ctx, s)
+	if err != nil {
+		return PrinterSnapshot{Printer: s}, errors.New("version endpoint - " + err.Error())
+	}
+
+	if err := ctx.Err(); err != nil {
+		return PrinterSnapshot{Printer: s}, err
+	}
+
+	snapshot := newSnapshot(s, printer, job)
+
+	for _, v := range files.Files {
+		snapshot.FileCounts = append(snapshot.FileCounts, FileCount{Storage: GetStorageName(v), Files: float64(len(v.Children))})
+	}
+
+	// buddy firmware does not report size and date in /api/files, its inventory is read from v1 files endpoint
+	if configuration.Exporter.Prusalink.Files.Inventory && printerBoards[s.Type] != "buddy" {
+		snapshot.Files = getInventory(s, files)
+	}
+
+	snapshot.Bed = &Heater{Temp: printer.Temperature.Bed.Actual, Target: printer.Temperature.Bed.Target, Offset: ref(printer.Temperature.Bed.Offset)}
+
+	var status Status
+
+	// metrics specific for both buddy and einsy
+	if printerBoards[s.Type] == "buddy" || printerBoards[s.Type] == "einsy" {
+
+		status, err = GetStatus(ctx, s)
+
+		if err != nil {
+			log.Error().Msg("Error while scraping status endpoint at " + s.Address + " - " + err.Error())
+		}
+
+		info, err := GetInfo(ctx, s)
+
+		if err != nil {
+			log.Error().Msg("Error while scraping info endpoint at " + s.Address + " - " + err.Error())
+		}
+
+		// only einsy related metrics
+		if printerBoards[s.Type] == "einsy" {
+			fetchEinsy(ctx, s, &snapshot)
+		}
+
+		storage, err := GetStorageV1(ctx, s)
+
+		if err != nil {
+			log.Error().Msg("Error while scraping storage endpoint at " + s.Address + " - " + err.Error())
+		} else {
+			for _, v := range storage.StorageList {
+				snapshot.Storage = append(snapshot.Storage, Storage{Name: v.Name, Type: v.Type, Available: v.Available, ReadOnly: v.ReadOnly,
+					Free: v.FreeSpace, Total: v.TotalSpace, PrintFiles: v.PrintFiles, SystemFiles: v.SystemFiles,
+					Files: getStorageFiles(snapshot.FileCounts, v.Name)})
+			}
+
+			if configuration.Exporter.Prusalink.Files.Inventory && printerBoards[s.Type] == "buddy" {
+				snapshot.Files = getInventoryV1(ctx, s, storage)
+			}
+		}
+
+		snapshot.Info = &PrinterInfo{API: version.API, Server: version.Server, Firmware: version.Text,
+			Name: info.Name, Location: info.Location, Serial: info.Serial, Hostname: info.Hostname}
+		snapshot.Fans = []Fan{{Name: "hotend", Speed: status.Printer.FanHotend}, {Name: "print", Speed: status.Printer.FanPrint}}
+		snapshot.NozzleDiameter = ref(info.NozzleDiameter)
+		snapshot.PrintSpeed = ref(printer.Telemetry.PrintSpeed / 100)
+		snapshot.Flow = ref(status.Printer.Flow / 100)
+		snapshot.Material = &Material{Name: printer.Telemetry.Material, Loaded: !strings.Contains(printer.Telemetry.Material, "-")}
+		snapshot.Axis = []Axis{{"x", printer.Telemetry.AxisX}, {"y", printer.Telemetry.AxisY}, {"z", printer.Telemetry.AxisZ}}
+		snapshot.Job.Progress = ref(job.Progress.Completion)
+		snapshot.Job.PrintTime = ref(job.Progress.PrintTime)
+		snapshot.Job.TimeRemaining = ref(job.Progress.PrintTimeLeft)
+
+		// metadata of the file and accuracy of estimates are in v1 job endpoint
+		if job.Job.File.Name != "" {
+			fetchJobV1(ctx, s, &snapshot)
+		}
+
+		if printerBoards[s.Type] == "buddy" {
+			snapshot.MMU = ref(info.Mmu)
+		}
+
+		if info.Mmu && !toolchangers[s.Type] {
+			snapshot.MMUSlots = getMMUSlots(status)
+		}
+	}
+
+	// only sl related metrics
+	if printerBoards[s.Type] == "sl" {
+		snapshot.Fans = []Fan{{"blower", printer.Telemetry.FanBlower}, {"rear", printer.Telemetry.FanRear}, {"uv", printer.Telemetry.FanUvLed}}
+		snapshot.Chamber = &Heater{Temp: printer.Temperature.Chamber.Actual, Target: printer.Temperature.Chamber.Target,
+			Offset: ref(printer.Temperature.Chamber.Offset)}
+		snapshot.SL = getSL(ctx, s, job)
+		snapshot.SL.Cover = printer.Telemetry.CoverClosed
+		snapshot.SL.AmbientTemp = printer.Telemetry.TempAmbient
+		snapshot.SL.CPUTemp = printer.Telemetry.TempCPU
+		snapshot.SL.UVLedTemp = printer.Telemetry.TempUvLed
+	}
+
+	if toolchangers[s.Type] && len(status.Printer.Slot.Slots) > 0 {
+		snapshot.Tools = getTools(status, printer.Temperature.Tool0.Offset)
+	} else {
+		snapshot.Tools = []Tool{{ID: "0", Heater: Heater{Temp: printer.Temperature.Tool0.Actual, Target: printer.Temperature.Tool0.Target,
+			Offset: ref(printer.Temperature.Tool0.Offset)}}}
+	}
+
+	log.Debug().Msg("Scraping done at " + s.Address)
+	return snapshot, nil
+}
+
+// fetchEinsy adds farm mode and cameras of PrusaLink on Raspberry Pi to the snapshot
+func fetchEinsy(ctx context.Context, s config.Printers, snapshot *PrinterSnapshot) {
+	settings, err := GetSettings(ctx, s)
+
+	if err != nil {
+		log.Error().Msg("Error while scraping settings endpoint at " + s.Address + " - " + err.Error())
+	} else {
+		snapshot.FarmMode = ref(settings.Printer.FarmMode)
+	}
+
+	cameras, err := GetCameras(ctx, s)
+
+	if err != nil {
+		log.Error().Msg("Error while scraping cameras endpoint at " + s.Address + " - " + err.Error())
+	} else {
+		for _, v := range cameras.CameraList {
+			snapshot.Cameras = append(snapshot.Cameras, Camera{ID: v.CameraID, Name: v.Config.Name, Resolution: v.Config.Resolution, Connected: v.Connected})
+		}
+	}
+
+	if configuration.Exporter.Prusalink.Cameras.Enabled {
+		snapshot.CameraSnapshots = GetCameraSnapshots(s.Address)
+	}
+}
+
+// fetchJobV1 adds filament and estimate of the printed file and accuracy of firmware estimates from v1 job endpoint to the snapshot
+func fetchJobV1(ctx context.Context, s config.Printers, snapshot *PrinterSnapshot) {
+	jobV1, err := GetJobV1(ctx, s)
+	if err != nil {
+		log.Error().Msg("Error while scraping v1 job endpoint at " + s.Address + " - " + err.Error())
+		return
+	}
+
+	snapshot.Job.Material = jobV1.File.Meta.FilamentType
+	if grams, ok := parseFilamentUsed(jobV1.File.Meta.FilamentUsedG); ok {
+		snapshot.Job.Filament = &grams
+	}
+	if jobV1.File.Meta.EstimatedPrintTime > 0 {
+		snapshot.Job.Estimate = ref(jobV1.File.Meta.EstimatedPrintTime)
+	}
+	snapshot.Job.InaccurateEstimates = ref(jobV1.InaccurateEstimates)
+}
+
+// getTools returns every tool of toolchanger printer, tool ID is numbered from 0 as in syslog metrics.
+// Offset is known only for the first tool from /api/printer.
+func getTools(status Status, offset float64) []Tool {
+	active := GetToolLabel(strconv.Itoa(int(status.Printer.Slot.Active)))
+
+	var tools []Tool
+	for slot, v := range status.Printer.Slot.Slots {
+		tool := Tool{
+			ID:             GetToolLabel(slot),
+			Heater:         Heater{Temp: v.Temp, Target: v.Target},
+			NozzleDiameter: ref(v.NozzleDiameter),
+			Material:       &Material{Name: v.Material, Loaded: isLoaded(v.Material)},
+			Fans:           []Fan{{"hotend", v.FanHotend}, {"print", v.FanPrint}},
+		}
+		tool.Active = ref(tool.ID == active)
+		if tool.ID == "0" {
+			tool.Offset = ref(offset)
+		}
+		tools = append(tools, tool)
+	}
+
+	sort.Slice(tools, func(i, j int) bool { return tools[i].ID < tools[j].ID })
+	return tools
+}
+
+// getMMUSlots returns filament slots of MMU, slot is numbered from 1 as in PrusaLink
+func getMMUSlots(status Status) []MMUSlot {
+	active := strconv.Itoa(int(status.Printer.Slot.Active))
+
+	var slots []MMUSlot
+	for slot, filament := range status.Printer.Slot.Slots {
+		slots = append(slots, MMUSlot{Slot: slot, Active: slot == active, Material: Material{Name: filament.Material, Loaded: isLoaded(filament.Material)}})
+	}
+
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Slot < slots[j].Slot })
+	return slots
+}
+
+// getSL returns resin, layer, exposure, tilt and UV LED data of SL printers, telemetry that firmware does not report is nil
+func getSL(ctx context.Context, s config.Printers, job Job) *SLSnapshot {
+	sl := &SLSnapshot{}
+
+	printer, err := GetSLPrinter(ctx, s)
+	if err != nil {
+		log.Error().Msg("Error while scraping printer endpoint at " + s.Address + " - " + err.Error())
+		return sl
+	}
+
+	telemetry := printer.Telemetry
+	sl.ResinLevel = telemetry.ResinLevel
+	sl.ResinRemaining = telemetry.ResinRemaining
+	sl.ResinLow = telemetry.ResinLow
+	sl.TankFull = telemetry.TankFull
+	sl.TiltTimeFast = telemetry.TiltTimeFast
+	sl.TiltTimeSlow = telemetry.TiltTimeSlow
+
+	if telemetry.UvLedHours != nil {
+		sl.UVLedUsage = ref(*telemetry.UvLedHours * 3600)
+	}
+
+	if job.Job.File.Name == "" {
+		return sl // layers, exposure and consumption are known only for a running job
+	}
+
+	sl.ResinUsed = telemetry.ResinUsed
+
+	slJob, err := GetSLJob(ctx, s)
+	if err != nil {
+		log.Error().Msg("Error while scraping job endpoint at " + s.Address + " - " + err.Error())
+		return sl
+	}
+
+	sl.Layers = &Layers{
+		Current:                 slJob.Progress.CurrentLayer,
+		Total:                   slJob.Progress.TotalLayers,
+		ExposureTime:            slJob.Job.ExposureTime,
+		ExposureTimeFirst:       slJob.Job.ExposureTimeFirst,
+		ExposureTimeCalibration: slJob.Job.ExposureTimeCalibration,
+	}
+	return sl
+}
+
+// getFileLimit returns maximum number of files in the file inventory of one printer
+func getFileLimit() int {
+	if limit := configuration.Exporter.Prusalink.Files.Limit; limit > 0 {
+		return limit
+	}
+	return defaultFileLimit
+}
+
+// getInventory returns size and modification time of files from /api/files, folders are walked recursively.
+// Number of files is limited by getFileLimit as every file is a new time series.
+func getInventory(s config.Printers, files Files) []InventoryFile {
+	limit := getFileLimit()
+	count := 0
+
+	var inventory []InventoryFile
+	var walk func(storage string, file File)
+	walk = func(storage string, file File) {
+		if file.Type == "folder" {
+			for _, child := range file.Children {
+				walk(storage, child)
+			}
+			return
+		}
+
+		count++
+		if count > limit {
+			return
+		}
+
+		name := file.Display
+		if name == "" {
+			name = file.Name
+		}
+
+		inventory = append(inventory, InventoryFile{Storage: storage, Path: file.Path, Name: name, Size: file.Size, Modified: file.Date})
+	}
+
+	for _, storage := range files.Files {
+		for _, child := range storage.Children {
+			walk(GetStorageName(storage), child)
+		}
+	}
+
+	if count > limit {
+		log.Warn().Msg("File inventory of " + s.Address + " has " + strconv.Itoa(count) + " files, only " + strconv.Itoa(limit) + " are exported")
+	}
+	return inventory
+}
+
+// getInventoryV1 returns size and modification time of files in root folder of every available storage of buddy printers.
+// Number of files is limited by getFileLimit as every file is a new time series.
+func getInventoryV1(ctx context.Context, s config.Printers, storage StorageV1) []InventoryFile {
+	limit := getFileLimit()
+	count := 0
+
+	var inventory []InventoryFile
+	for _, v := range storage.StorageList {
+		if !v.Available {
+			continue
+		}
+
+		files, err := GetFilesV1(ctx, s, v.Path)
+		if err != nil {
+			log.Error().Msg("Error while scraping files endpoint of " + v.Path + " at " + s.Address + " - " + err.Error())
+			continue
+		}
+
+		for _, file := range files.Children {
+			if file.Type == "FOLDER" {
+				continue
+			}
+
+			count++
+			if count > limit {
+				continue
+			}
+
+			name := file.DisplayName
+			if name == "" {
+				name = file.Name
+			}
+
+			inventory = append(inventory, InventoryFile{Storage: v.Name, Path: strings.TrimSuffix(v.Path, "/") + "/" + file.Name,
+				Name: name, Size: file.Size, Modified: file.MTimestamp})
+		}
+	}
+
+	if count > limit {
+		log.Warn().Msg("File inventory of " + s.Address + " has " + strconv.Itoa(count) + " files, only " + strconv.Itoa(limit) + " are exported")
+	}
+	return inventory
+}
+
+// getStorageFiles returns number of files of the storage, /api/files names USB storage of Buddy printers in upper case
+func getStorageFiles(counts []FileCount, name string) *float64 {
+	for _, count := range counts {
+		if strings.EqualFold(count.Storage, name) {
+			return ref(count.Files)
+		}
+	}
+	return nil
+}
@@ -109,34 +109,73 @@ type Printer struct {
 	} `json:"storage"`
 }
 
+// SLPrinter is a struct that contains SL specific data from /api/printer - resin, tilt and UV LED telemetry.
+// Fields are pointers because older firmware does not report them and zero would look like an empty tank.
+type SLPrinter struct {
+	Telemetry struct {
+		ResinLevel     *float64 `json:"resinLevel"`
+		ResinRemaining *float64 `json:"resinRemaining"`
+		ResinUsed      *float64 `json:"resinUsed"`
+		ResinLow       *bool    `json:"resinLow"`
+		TankFull       *bool    `json:"tankFull"`
+		TiltTimeFast   *float64 `json:"tiltTimeFast"`
+		TiltTimeSlow   *float64 `json:"tiltTimeSlow"`
+		UvLedHours     *float64 `json:"uvLedHours"`
+	} `json:"telemetry"`
+}
+
+// SLJob is a struct that contains SL specific data from /api/job - layers and exposure times
+type SLJob struct {
+	Job struct {
+		ExposureTime            float64 `json:"exposureTime"`
+		ExposureTimeFirst       float64 `json:"exposureTimeFirst"`
+		ExposureTimeCalibration float64 `json:"exposureTimeCalibration"`
+	} `json:"job"`
+	Progress struct {
+		CurrentLayer float64 `json:"currentLayer"`
+		TotalLayers  float64 `json:"totalLayers"`
+	} `json:"progress"`
+}
+
 // Files is a struct that contains data about the files on the printer
 type Files struct {
-	Files []struct {
-		Name     string `json:"name"`
-		Path     string `json:"path"`
-		Display  string `json:"display"`
-		Type     string `json:"type"`
-		Origin   string `json:"origin"`
-		Children []struct {
-			Name    string `json:"name"`
-			Display string `json:"display"`
-			Path    string `json:"path"`
-			Origin  string `json:"origin"`
-			Refs    struct {
-				Resource       string `json:"resource"`
-				ThumbnailSmall string `json:"thumbnailSmall"`
-				ThumbnailBig   string `json:"thumbnailBig"`
-				Download       string `json:"download"`
-			} `json:"refs"`
-		} `json:"children"`
-		Date     float64  `json:"date"`
-		Size     float64  `json:"size"`
-		TypePath []string `json:"typePath"`
-		Refs     struct {
-			Resource any `json:"resource"`
-		} `json:"refs"`
-		ReadOnly bool `json:"read_only,omitempty"`
-	} `json:"files"`
+	Files []File `json:"files"`
+}
+
+// File is a file or folder from path /api/files, folders contain their files in Children
+type File struct {
+	Name     string   `json:"name"`
+	Path     string   `json:"path"`
+	Display  string   `json:"display"`
+	Type     string   `json:"type"`
+	Origin   string   `json:"origin"`
+	Date     float64  `json:"date"`
+	Size     float64  `json:"size"`
+	TypePath []string `json:"typePath"`
+	ReadOnly bool     `json:"read_only,omitempty"`
+	Refs     struct {
+		Resource       any    `json:"resource"`
+		ThumbnailSmall string `json:"thumbnailSmall"`
+		ThumbnailBig   string `json:"thumbnailBig"`
+		Download       any    `json:"download"`
+	} `json:"refs"`
+	Children []File `json:"children"`
+}
+
+// FilesV1 is a struct that contains data about the files in the storage from path /api/v1/files/<storage>
+type FilesV1 struct {
+	Name       string  `json:"name"`
+	ReadOnly   bool    `json:"ro"`
+	Type       string  `json:"type"`
+	MTimestamp float64 `json:"m_timestamp"`
+	Children   []struct {
+		Name        string  `json:"name"`
+		DisplayName string  `json:"display_name"`
+		ReadOnly    bool    `json:"ro"`
+		Type        string  `json:"type"`
+		Size        float64 `json:"size"`
+		MTimestamp  float64 `json:"m_timestamp"`
+	} `json:"children"`
 }
 
 // JobV1 is a struct that contains data about the print job from path /api/v1/job
@@ -165,6 +204,7 @@ type JobV1 struct {
 			LayerHeight                     float64 `json:"layer_height"`
 			FilamentType                    string  `json:"filament_type"`
 			EstimatedPrintTime              float64 `json:"estimated_print_time"`
+			FilamentUsedG                   any     `json:"filament used [g]"` // number or comma separated list of tools
 		} `json:"meta"`
 	} `json:"file"`
 }
@@ -190,21 +230,37 @@ type Status struct {
 		Speed        float64 `json:"speed"`
 		FanHotend    float64 `json:"fan_hotend"`
 		FanPrint     float64 `json:"fan_print"`
+		Slot         struct {
+			Active float64         `json:"active"`
+			Slots  map[string]Slot `json:"slots"`
+		} `json:"slot"`
 	} `json:"printer"`
 }
 
+// Slot is a tool of toolchanger printer or a filament slot of MMU reported in /api/v1/status, slots are numbered from 1
+type Slot struct {
+	Material       string  `json:"material"`
+	Temp           float64 `json:"temp"`
+	Target         float64 `json:"target"`
+	FanHotend      float64 `json:"fan_hotend"`
+	FanPrint       float64 `json:"fan_print"`
+	NozzleDiameter float64 `json:"nozzle_diameter"`
+	HighFlow       bool    `json:"high_flow"`
+	Hardened       bool    `json:"hardened"`
+}
+
 // StorageV1 is a struct that contains data about the storage from path /api/v1/storage
 type StorageV1 struct {
 	StorageList []struct {
-		Path        string  `json:"path"`
-		Name        string  `json:"name"`
-		Type        string  `json:"type"`
-		ReadOnly    bool    `json:"read_only"`
-		Available   bool    `json:"available"`
-		FreeSpace   float64 `json:"free_space,omitempty"`
-		TotalSpace  float64 `json:"total_space,omitempty"`
-		PrintFiles  float64 `json:"print_files"`
-		SystemFiles float64 `json:"system_files"`
+		Path        string   `json:"path"`
+		Name        string   `json:"name"`
+		Type        string   `json:"type"`
+		ReadOnly    bool     `json:"read_only"`
+		Available   bool     `json:"available"`
+		FreeSpace   *float64 `json:"free_space,omitempty"` // buddy firmware does not report space of the storage
+		TotalSpace  *float64 `json:"total_space,omitempty"`
+		PrintFiles  *float64 `json:"print_files,omitempty"` // size of print files in bytes, reported only by PrusaLink of einsy printers
+		SystemFiles *float64 `json:"system_files,omitempty"`
 	} `json:"storage_list"`
 }
 
@@ -270,3 +326,60 @@ type Cameras struct {
 		Registered bool `json:"registered"`
 	} `json:"camera_list"`
 }
+
+// MoonrakerStatus is a struct that contains Klipper objects from Moonraker path /printer/objects/query
+type MoonrakerStatus struct {
+	Result struct {
+		Status struct {
+			Extruder struct {
+				Temperature float64 `json:"temperature"`
+				Target      float64 `json:"target"`
+			} `json:"extruder"`
+			HeaterBed struct {
+				Temperature float64 `json:"temperature"`
+				Target      float64 `json:"target"`
+			} `json:"heater_bed"`
+			PrintStats struct {
+				Filename      string  `json:"filename"`
+				TotalDuration float64 `json:"total_duration"`
+				PrintDuration float64 `json:"print_duration"`
+				FilamentUsed  float64 `json:"filament_used"`
+				State         string  `json:"state"` // standby, printing, paused, complete, cancelled or error
+				Message       string  `json:"message"`
+			} `json:"print_stats"`
+			DisplayStatus struct {
+				Progress float64 `json:"progress"` // ratio 0.0 - 1.0
+			} `json:"display_status"`
+			Toolhead struct {
+				Position []float64 `json:"position"` // x, y, z, e
+			} `json:"toolhead"`
+			Fan struct {
+				Speed float64  `json:"speed"` // ratio 0.0 - 1.0
+				RPM   *float64 `json:"rpm"`   // nil when the fan has no tachometer
+			} `json:"fan"`
+			GcodeMove struct {
+				SpeedFactor   float64 `json:"speed_factor"`
+				ExtrudeFactor float64 `json:"extrude_factor"`
+			} `json:"gcode_move"`
+		} `json:"status"`
+	} `json:"result"`
+}
+
+// MoonrakerInfo is a struct that contains data about Klipper from Moonraker path /printer/info
+type MoonrakerInfo struct {
+	Result struct {
+		State           string `json:"state"` // ready, startup, shutdown or error
+		StateMessage    string `json:"state_message"`
+		Hostname        string `json:"hostname"`
+		SoftwareVersion string `json:"software_version"`
+	} `json:"result"`
+}
+
+// MoonrakerServer is a struct that contains data about Moonraker from path /server/info
+type MoonrakerServer struct {
+	Result struct {
+		KlippyState      string `json:"klippy_state"`
+		MoonrakerVersion string `json:"moonraker_version"`
+		APIVersionString string `json:"api_version_string"`
+	} `json:"result"`
+}
@@ -0,0 +1,117 @@
+package simulator
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/icholy/digest"
+)
+
+const (
+	realm = "Printer API"
+
+	maxNonces     = 256             // most nonces remembered at once, the oldest one is forgotten first
+	nonceLifetime = 5 * time.Minute // nonce that was not used for this long is forgotten
+)
+
+// newNonce returns a new random nonce and remembers it as valid, expired nonces are forgotten so clients that never
+// answer the challenge do not grow the set
+func (s *Simulator) newNonce() string {
+	buffer := make([]byte, 16)
+	rand.Read(buffer)
+	nonce := hex.EncodeToString(buffer)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.options.Now()
+	oldest := ""
+	for known, used := range s.nonces {
+		if now.Sub(used) > nonceLifetime {
+			delete(s.nonces, known)
+		} else if oldest == "" || used.Before(s.nonces[oldest]) {
+			oldest = known
+		}
+	}
+	if len(s.nonces) >= maxNonces {
+		delete(s.nonces, oldest)
+	}
+	s.nonces[nonce] = now
+
+	return nonce
+}
+
+// validDigest checks the digest authorization header of the request
+func (s *Simulator) validDigest(r *http.Request) bool {
+	header := r.Header.Get("Authorization")
+	if !digest.IsDigest(header) {
+		return false
+	}
+
+	credentials, err := digest.ParseCredentials(header)
+	if err != nil || credentials.Username != s.options.Username || credentials.Realm != realm {
+		return false
+	}
+
+	s.mutex.Lock()
+	used, known := s.nonces[credentials.Nonce]
+	known = known && s.options.Now().Sub(used) <= nonceLifetime
+	if known {
+		s.nonces[credentials.Nonce] = s.options.Now()
+	}
+	s.mutex.Unlock()
+
+	if !known {
+		return false
+	}
+
+	challenge := &digest.Challenge{
+		Realm:     realm,
+		Nonce:     credentials.Nonce,
+		Opaque:    credentials.Opaque,
+		Algorithm: credentials.Algorithm,
+		QOP:       []string{"auth"},
+	}
+
+	expected, err := digest.Digest(challenge, digest.Options{
+		Method:   r.Method,
+		URI:      credentials.URI,
+		Count:    credentials.Nc,
+		Cnonce:   credentials.Cnonce,
+		Username: s.options.Username,
+		Password: s.options.Password,
+	})
+
+	return err == nil && expected.Response == credentials.Response
+}
+
+// authorized checks api key or digest authentication of the request and writes 401 response when it fails.
+// Requests are always authorized when the simulator has neither api key nor password configured.
+func (s *Simulator) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if s.options.APIKey == "" && s.options.Password == "" {
+		return true
+	}
+
+	if s.options.APIKey != "" && r.Header.Get("X-Api-Key") == s.options.APIKey {
+		return true
+	}
+
+	if s.options.Password != "" {
+		if s.validDigest(r) {
+			return true
+		}
+
+		challenge := &digest.Challenge{
+			Realm:     realm,
+			Nonce:     s.newNonce(),
+			Algorithm: "MD5",
+			QOP:       []string{"auth"},
+		}
+		w.Header().Set("WWW-Authenticate", challenge.String())
+	}
+
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	return false
+}
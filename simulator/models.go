@@ -0,0 +1,97 @@
+package simulator
+
+import "sort"
+
+// Model is a struct that describes how a simulated printer identifies itself and what hardware it has
+type Model struct {
+	Type           string  // printer type as used in prusa.yml - e.g. "MINI", "MK4", "XL", "I3MK3S", "SL1S"
+	Board          string  // buddy, einsy or sl
+	Hostname       string  // hostname returned by /api/version
+	Original       string  // original returned by /api/version - only einsy boards fill it
+	API            string  // api version returned by /api/version
+	Server         string  // server version returned by /api/version
+	Text           string  // text returned by /api/version
+	Firmware       string  // firmware version of the printer
+	Serial         string  // serial number returned by /api/v1/info
+	Tools          int     // number of tools (heads) of the printer
+	NozzleDiameter float64 // nozzle diameter in mm
+	BedTarget      float64 // bed temperature target used while printing
+	NozzleTarget   float64 // nozzle temperature target used while printing
+	BuddyBom       int     // bom id sent in buddy_bom syslog metric
+	BuddyRevision  int     // board revision sent in buddy_revision syslog metric
+	Loadcell       bool    // printer has loadcell and sends loadcell syslog metrics
+	PrinterModel   string  // printer_model in metadata of the printed file as written by PrusaSlicer
+	ExposureTime   float64 // exposure time of one layer in seconds - only sl boards
+	Layers         int     // number of layers of the printed job - only sl boards
+	JobFile        string  // name of the file that is printed by the simulated job
+}
+
+var (
+	// models contains all printers that can be simulated, values are based on payloads recorded from real printers
+	models = map[string]Model{
+		"MINI": {
+			Type: "MINI", Board: "buddy", Hostname: "PrusaMINI", API: "2.0.0", Server: "2.1.2", Text: "PrusaLink", Firmware: "6.0.0+14794",
+			Serial: "10562-1342441631728135", Tools: 1, NozzleDiameter: 0.4, BedTarget: 60, NozzleTarget: 215,
+			BuddyBom: 0, BuddyRevision: 14, PrinterModel: "MINI", JobFile: "benchy_0.4n_0.2mm_PLA_MINI_1h5m.bgcode",
+		},
+		"MK35": {
+			Type: "MK35", Board: "buddy", Hostname: "PrusaMK4", API: "2.0.0", Server: "2.1.2", Text: "PrusaLink", Firmware: "6.0.0+14794",
+			Serial: "10582-3742441631728111", Tools: 1, NozzleDiameter: 0.4, BedTarget: 60, NozzleTarget: 215,
+			BuddyBom: 30, BuddyRevision: 14, PrinterModel: "MK3.5", JobFile: "benchy_0.4n_0.2mm_PLA_MK3.5_1h2m.bgcode",
+		},
+		"MK39": {
+			Type: "MK39", Board: "buddy", Hostname: "PrusaMK4", API: "2.0.0", Server: "2.1.2", Text: "PrusaLink", Firmware: "6.0.0+14794",
+			Serial: "10589-3742441631728120", Tools: 1, NozzleDiameter: 0.4, BedTarget: 60, NozzleTarget: 215,
+			BuddyBom: 14, BuddyRevision: 27, Loadcell: true, PrinterModel: "MK3.9", JobFile: "benchy_0.4n_0.2mm_PLA_MK3.9_50m.bgcode",
+		},
+		"MK4": {
+			Type: "MK4", Board: "buddy", Hostname: "PrusaMK4", API: "2.0.0", Server: "2.1.2", Text: "PrusaLink", Firmware: "6.0.0+14794",
+			Serial: "10589-3742441631728135", Tools: 1, NozzleDiameter: 0.4, BedTarget: 60, NozzleTarget: 215,
+			BuddyBom: 34, BuddyRevision: 37, Loadcell: true, PrinterModel: "MK4IS", JobFile: "benchy_0.4n_0.2mm_PLA_MK4IS_45m.bgcode",
+		},
+		"XL": {
+			Type: "XL", Board: "buddy", Hostname: "PrusaXL", API: "2.0.0", Server: "2.1.2", Text: "PrusaLink", Firmware: "6.0.0+14794",
+			Serial: "10595-3742441631728142", Tools: 5, NozzleDiameter: 0.4, BedTarget: 60, NozzleTarget: 215,
+			BuddyBom: 7, BuddyRevision: 10, Loadcell: true, PrinterModel: "XL5IS", JobFile: "multiple_grots_0.4n_0.15mm_PLA,PLA,PLA,PLA_XLIS_5h36m.bgcode",
+		},
+		"MINIPLUS": {
+			Type: "MINIPLUS", Board: "buddy", Hostname: "PrusaMINI", API: "2.0.0", Server: "2.1.2", Text: "PrusaLink", Firmware: "6.0.0+14794",
+			Serial: "10562-1342441631728177", Tools: 1, NozzleDiameter: 0.4, BedTarget: 60, NozzleTarget: 215,
+			BuddyBom: 0, BuddyRevision: 14, PrinterModel: "MINIIS", JobFile: "benchy_0.4n_0.2mm_PLA_MINIIS_1h3m.bgcode",
+		},
+		"COREONE": {
+			Type: "COREONE", Board: "buddy", Hostname: "PrusaCOREONE", API: "2.0.0", Server: "2.1.2", Text: "PrusaLink", Firmware: "6.2.0+8865",
+			Serial: "10619-3742441631728151", Tools: 1, NozzleDiameter: 0.4, BedTarget: 60, NozzleTarget: 215,
+			BuddyBom: 34, BuddyRevision: 37, Loadcell: true, PrinterModel: "COREONE", JobFile: "benchy_0.4n_0.2mm_PLA_COREONE_35m.bgcode",
+		},
+		"I3MK3S": {
+			Type: "I3MK3S", Board: "einsy", Hostname: "mk3", Original: "PrusaLink I3MK3S", API: "0.9.0-legacy", Server: "0.7.2",
+			Text: "PrusaLink 0.7.2", Firmware: "3.13.1-6876", Serial: "CZPX5222X004XK04220", Tools: 1, NozzleDiameter: 0.4,
+			BedTarget: 60, NozzleTarget: 215, PrinterModel: "MK3SMMU3", JobFile: "fosdem_0.2mm_PLA,PLA_MK3SMMU3_7h16m.gcode",
+		},
+		"SL1": {
+			Type: "SL1", Board: "sl", Hostname: "prusa-sl1", API: "0.1", Server: "1.1.0", Text: "Prusa SLA 1.0.5",
+			Firmware: "1.7.6", Serial: "CZPX0819X009XC00231", Tools: 1, PrinterModel: "SL1", ExposureTime: 6, Layers: 540, JobFile: "Resin_Calibration_Object_0.100.sl1",
+		},
+		"SL1S": {
+			Type: "SL1S", Board: "sl", Hostname: "prusa-sl1s", API: "0.1", Server: "1.1.0", Text: "Prusa SLA 1.0.5",
+			Firmware: "1.8.1", Serial: "CZPX2021X009XS00315", Tools: 1, PrinterModel: "SL1S", ExposureTime: 2, Layers: 1080, JobFile: "Resin_Calibration_Object_0.050.sl1",
+		},
+	}
+)
+
+// GetModel returns the simulated model for the given printer type
+func GetModel(printerType string) (Model, bool) {
+	model, ok := models[printerType]
+	return model, ok
+}
+
+// Models returns printer types of all models that can be simulated
+func Models() []string {
+	var types []string
+	for printerType := range models {
+		types = append(types, printerType)
+	}
+	sort.Strings(types)
+	return types
+}
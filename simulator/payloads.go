@@ -0,0 +1,547 @@
+package simulator
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// object is a shorthand for JSON objects of the payloads
+type object = map[string]any
+
+// writeJSON writes the payload as JSON response
+func writeJSON(w http.ResponseWriter, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payload)
+}
+
+// writeThumbnail writes PNG thumbnail of the printed file - small orange rectangle same for all files
+func writeThumbnail(w http.ResponseWriter) {
+	thumbnail := image.NewRGBA(image.Rect(0, 0, 16, 12))
+	for x := 0; x < 16; x++ {
+		for y := 0; y < 12; y++ {
+			thumbnail.Set(x, y, color.RGBA{R: 0xfa, G: 0x68, B: 0x31, A: 0xff})
+		}
+	}
+	w.Header().Set("Content-Type", "image/png")
+	png.Encode(w, thumbnail)
+}
+
+// cameras returns cameras connected to PrusaLink, ids are camera1, camera2...
+func (s *Simulator) cameras() []object {
+	cameras := []object{}
+	for i := 1; i <= s.options.Cameras; i++ {
+		id := "camera" + strconv.Itoa(i)
+		cameras = append(cameras, object{
+			"camera_id": id,
+			"config": object{
+				"id_string": "/dev/video" + strconv.Itoa(i-1), "name": "Simulated camera " + strconv.Itoa(i),
+				"driver": "V4L2", "resolution": "640x480", "trigger_scheme": "THIRTY_SEC",
+			},
+			"connected": true, "detected": true, "stored": true, "registered": false,
+		})
+	}
+	return cameras
+}
+
+// writeSnapshot writes JPEG snapshot of the camera from path /api/v1/cameras/<id>/snap, /api/v1/cameras/snap is the first camera
+func (s *Simulator) writeSnapshot(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/cameras/"), "snap")
+	id = strings.TrimSuffix(id, "/")
+	if id == "" {
+		id = "camera1"
+	}
+
+	number, err := strconv.Atoi(strings.TrimPrefix(id, "camera"))
+	if err != nil || number < 1 || number > s.options.Cameras {
+		http.NotFound(w, r)
+		return
+	}
+
+	snapshot := image.NewGray(image.Rect(0, 0, 64, 48))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 48; y++ {
+			snapshot.SetGray(x, y, color.Gray{Y: uint8(x * 4)})
+		}
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	jpeg.Encode(w, snapshot, nil)
+}
+
+// legacyStateText returns state text of the OctoPrint compatible endpoints for the given state
+func legacyStateText(state string) string {
+	switch state {
+	case "PRINTING":
+		return "Printing"
+	case "PAUSED":
+		return "Paused"
+	case "ERROR":
+		return "Error"
+	case "FINISHED":
+		return "Finished"
+	case "STOPPED":
+		return "Operational"
+	case "ATTENTION":
+		return "Busy"
+	default:
+		return "Operational"
+	}
+}
+
+// payload returns JSON payload for the given path and status code - nil payload means the endpoint does not exist for the model
+func (s *Simulator) payload(path string, snap snapshot) (any, int) {
+	board := s.model.Board
+
+	switch path {
+	case "/api/version":
+		return s.version(), http.StatusOK
+	case "/api/job":
+		return s.job(snap), http.StatusOK
+	case "/api/printer":
+		return s.printer(snap), http.StatusOK
+	case "/api/files":
+		return s.files(), http.StatusOK
+	}
+
+	if board == "sl" {
+		switch path {
+		case "/api/printerprofiles", "/api/v1/printerprofiles":
+			return s.printerProfiles(), http.StatusOK
+		}
+		return nil, http.StatusNotFound
+	}
+
+	switch path {
+	case "/api/v1/status":
+		return s.status(snap), http.StatusOK
+	case "/api/v1/job":
+		if !snap.Job {
+			return nil, http.StatusNoContent
+		}
+		return s.jobV1(snap), http.StatusOK
+	case "/api/v1/info":
+		return s.info(), http.StatusOK
+	case "/api/v1/storage":
+		return object{"storage_list": s.storage()}, http.StatusOK
+	}
+
+	if board == "buddy" && path == "/api/v1/files/usb" {
+		return s.filesV1(), http.StatusOK
+	}
+
+	if board == "einsy" {
+		switch path {
+		case "/api/settings":
+			return object{
+				"api-key":  s.options.APIKey,
+				"username": s.options.Username,
+				"printer":  object{"name": "Simulated " + s.model.Type, "location": "Simulator", "farm_mode": false},
+			}, http.StatusOK
+		case "/api/v1/cameras":
+			return object{"camera_list": s.cameras()}, http.StatusOK
+		}
+	}
+
+	return nil, http.StatusNotFound
+}
+
+func (s *Simulator) version() object {
+	version := object{
+		"api":          s.model.API,
+		"server":       s.model.Server,
+		"text":         s.model.Text,
+		"hostname":     s.model.Hostname,
+		"capabilities": object{"upload-by-put": true},
+	}
+
+	switch s.model.Board {
+	case "buddy":
+		version["nozzle_diameter"] = s.model.NozzleDiameter
+	case "einsy":
+		version["original"] = s.model.Original
+		version["firmware"] = s.model.Firmware
+		version["sdk"] = "0.7.1"
+	case "sl":
+		delete(version, "capabilities")
+	}
+
+	return version
+}
+
+func (s *Simulator) filePath() string {
+	switch s.model.Board {
+	case "einsy":
+		return "/SD Card/" + s.model.JobFile
+	case "sl":
+		return "local/" + s.model.JobFile
+	default:
+		return "/usb/" + shortName(s.model.JobFile)
+	}
+}
+
+// shortName returns 8.3 file name same as buddy firmware does for long names
+func shortName(name string) string {
+	base, extension := name, ""
+	if i := strings.LastIndex(name, "."); i != -1 {
+		base, extension = name[:i], name[i+1:]
+	}
+	base = strings.ToUpper(strings.ReplaceAll(base, " ", ""))
+	if len(base) > 6 {
+		base = base[:6]
+	}
+	extension = strings.ToUpper(extension)
+	if len(extension) > 3 {
+		extension = extension[:3]
+	}
+	return base + "~1." + extension
+}
+
+func (s *Simulator) job(snap snapshot) object {
+	if !snap.Job {
+		if s.model.Board == "sl" {
+			return object{"state": "Ready"}
+		}
+		return object{"state": legacyStateText(snap.State)}
+	}
+
+	job := object{
+		"state": legacyStateText(snap.State),
+		"job": object{
+			"estimatedPrintTime": snap.TimePrinting + snap.TimeRemaining,
+			"file": object{
+				"name":    s.model.JobFile,
+				"path":    s.filePath(),
+				"display": s.model.JobFile,
+			},
+		},
+		"progress": object{
+			"printTimeLeft": snap.TimeRemaining,
+			"completion":    snap.Progress / 100,
+			"printTime":     snap.TimePrinting,
+		},
+	}
+
+	if s.model.Board == "sl" {
+		job["job"].(object)["exposureTime"] = s.model.ExposureTime
+		job["job"].(object)["exposureTimeFirst"] = 35
+		job["job"].(object)["exposureTimeCalibration"] = s.model.ExposureTime + 2
+		job["progress"].(object)["currentLayer"] = int(snap.Progress / 100 * float64(s.model.Layers))
+		job["progress"].(object)["totalLayers"] = s.model.Layers
+	}
+
+	return job
+}
+
+func (s *Simulator) flags(snap snapshot) object {
+	return object{
+		"link_state":    snap.State,
+		"operational":   !snap.Job || snap.State == "FINISHED" || snap.State == "STOPPED",
+		"paused":        snap.State == "PAUSED",
+		"printing":      snap.State == "PRINTING",
+		"cancelling":    false,
+		"pausing":       false,
+		"error":         snap.State == "ERROR",
+		"sdReady":       s.model.Board != "buddy",
+		"closedOnError": false,
+		"ready":         !snap.Job,
+		"busy":          snap.State == "ATTENTION",
+		"finished":      snap.State == "FINISHED",
+	}
+}
+
+func (s *Simulator) printer(snap snapshot) object {
+	if s.model.Board == "sl" {
+		printing := snap.State == "PRINTING"
+		resinUsed := 0.0
+		if snap.Job {
+			resinUsed = math.Round(snap.Progress/100*resinJob*10) / 10
+		}
+		fan := 0.0
+		uvTemp := 26.5
+		if printing {
+			fan = 1980
+			uvTemp = 41.3
+		}
+		return object{
+			"sd":    []object{{"ready": false}},
+			"state": object{"text": legacyStateText(snap.State), "flags": s.flags(snap)},
+			"telemetry": object{
+				"coverClosed": true,
+				"fanBlower":   fan,
+				"fanRear":     fan,
+				"fanUvLed":    fan,
+				"tempAmbient": 24.2,
+				"tempCpu":     51.1,
+				"tempUvLed":   uvTemp,
+				// resin is consumed during the job, the tank is refilled with every new job
+				"resinLevel":     math.Round((resinTank-resinUsed)/resinTank*1000) / 10,
+				"resinRemaining": math.Round((resinTank-resinUsed)*10) / 10,
+				"resinUsed":      resinUsed,
+				"resinLow":       resinTank-resinUsed < resinJob,
+				"tankFull":       false,
+				"tiltTimeFast":   5.2,
+				"tiltTimeSlow":   9.8,
+				"uvLedHours":     812.4 + snap.TimePrinting/3600,
+			},
+			"temperature": object{
+				"bed":     object{"actual": 51.1, "offset": 0, "target": 0},
+				"chamber": object{"actual": 24.2, "offset": 0, "target": 0},
+				"tool0":   object{"actual": uvTemp, "offset": 0, "target": 0},
+			},
+		}
+	}
+
+	material := snap.Material
+	if s.model.Board == "einsy" {
+		material = " - "
+	}
+
+	printer := object{
+		"telemetry": object{
+			"temp-bed":    snap.TempBed,
+			"temp-nozzle": snap.TempNozzle,
+			"print-speed": snap.Speed,
+			"z-height":    snap.AxisZ,
+			"material":    material,
+			"axis_x":      snap.AxisX,
+			"axis_y":      snap.AxisY,
+			"axis_z":      snap.AxisZ,
+		},
+		"temperature": object{
+			"tool0": object{"actual": snap.TempNozzle, "target": snap.TargetNozzle, "display": snap.TargetNozzle, "offset": 0},
+			"bed":   object{"actual": snap.TempBed, "target": snap.TargetBed, "offset": 0},
+		},
+		"state": object{"text": legacyStateText(snap.State), "flags": s.flags(snap)},
+	}
+
+	if s.model.Board == "einsy" {
+		printer["storage"] = object{
+			"local":   object{"free_space": 27429453824.0, "total_space": 30323138560.0},
+			"sd_card": nil,
+		}
+	}
+
+	return printer
+}
+
+func (s *Simulator) files() object {
+	switch s.model.Board {
+	case "einsy":
+		return object{"files": []object{
+			{
+				"name": "PrusaLink gcodes", "path": "/PrusaLink gcodes", "display": "PrusaLink gcodes", "date": 1697207679, "size": 0,
+				"type": "folder", "typePath": []string{"folder"}, "origin": "local", "refs": object{"resource": nil}, "children": []object{},
+			},
+			{
+				"name": "SD Card", "path": "/SD Card", "display": "SD Card", "date": nil, "size": 20086729, "read_only": true,
+				"type": "folder", "typePath": []string{"folder"}, "origin": "sdcard", "refs": object{"resource": nil},
+				"children": []object{{
+					"name": s.model.JobFile, "path": s.filePath(), "display": s.model.JobFile, "date": 1706813720, "size": 20086729,
+					"origin": "sdcard", "type": "machinecode", "typePath": []string{"machinecode", "gcode"},
+					"refs": object{"resource": nil, "download": nil},
+				}},
+			},
+		}}
+	case "sl":
+		return object{"files": []object{{
+			"path": "local", "origin": "local", "type": "folder",
+			"children": []object{{
+				"path": s.filePath(), "origin": "local", "type": "machinecode", "size": 1333934, "name": s.model.JobFile,
+				"display": s.model.JobFile, "date": 1706726206.618253, "typePath": []string{"machinecode", "gcode"},
+				"refs": object{"resource": "/api/files/" + s.filePath(), "download": "/api/downloads/" + s.filePath()},
+			}},
+		}}}
+	default:
+		name := shortName(s.model.JobFile)
+		return object{"files": []object{{
+			"name": "USB", "path": "/usb", "display": "USB", "type": "folder", "origin": "usb",
+			"children": []object{{
+				"name": name, "display": s.model.JobFile, "path": "usb/" + name, "origin": "usb",
+				"refs": object{
+					"resource":       "/api/files/usb/" + name,
+					"thumbnailSmall": "/thumb/s/usb/" + name,
+					"thumbnailBig":   "/thumb/l/usb/" + name,
+					"download":       "usb/" + name,
+				},
+			}},
+		}}}
+	}
+}
+
+func (s *Simulator) filesV1() object {
+	name := shortName(s.model.JobFile)
+	return object{
+		"name": "usb", "ro": false, "type": "FOLDER", "m_timestamp": 1706813720,
+		"children": []object{{
+			"name": name, "display_name": s.model.JobFile, "ro": false, "type": "PRINT_FILE", "size": 1832429, "m_timestamp": 1706813720,
+			"refs": object{
+				"icon":      "/thumb/s/usb/" + name,
+				"thumbnail": "/thumb/l/usb/" + name,
+				"download":  "/usb/" + name,
+			},
+		}},
+	}
+}
+
+func (s *Simulator) status(snap snapshot) object {
+	status := object{
+		"printer": object{
+			"state":         snap.State,
+			"temp_bed":      snap.TempBed,
+			"target_bed":    snap.TargetBed,
+			"temp_nozzle":   snap.TempNozzle,
+			"target_nozzle": snap.TargetNozzle,
+			"axis_x":        snap.AxisX,
+			"axis_y":        snap.AxisY,
+			"axis_z":        snap.AxisZ,
+			"flow":          snap.Flow,
+			"speed":         snap.Speed,
+			"fan_hotend":    snap.FanHotend,
+			"fan_print":     snap.FanPrint,
+		},
+	}
+
+	if s.model.Tools > 1 {
+		slots := object{}
+		for tool := 1; tool <= s.model.Tools; tool++ {
+			slot := object{
+				"material":        snap.Material,
+				"temp":            ambientTemp,
+				"target":          0,
+				"fan_hotend":      0,
+				"fan_print":       0,
+				"nozzle_diameter": s.model.NozzleDiameter,
+				"high_flow":       false,
+				"hardened":        false,
+			}
+			if tool == 1 { // simulated job is printed by the first tool
+				slot["temp"], slot["target"] = snap.TempNozzle, snap.TargetNozzle
+				slot["fan_hotend"], slot["fan_print"] = snap.FanHotend, snap.FanPrint
+			}
+			slots[strconv.Itoa(tool)] = slot
+		}
+		status["printer"].(object)["slot"] = object{"active": 1, "slots": slots}
+	}
+
+	if s.options.MMU && s.model.Tools == 1 {
+		slots := object{}
+		for slot := 1; slot <= mmuSlots; slot++ {
+			slots[strconv.Itoa(slot)] = object{"material": snap.Material}
+		}
+		status["printer"].(object)["slot"] = object{"active": s.mmuSlot(snap), "slots": slots}
+	}
+
+	if snap.Job {
+		status["job"] = object{
+			"id":             snap.JobID,
+			"progress":       snap.Progress,
+			"time_remaining": snap.TimeRemaining,
+			"time_printing":  snap.TimePrinting,
+		}
+	}
+
+	if s.model.Board == "einsy" {
+		status["storage"] = []object{
+			{"path": "/local", "read_only": false, "free_space": 27429449728.0, "name": "PrusaLink gcodes"},
+			{"path": "/sdcard", "read_only": true, "name": "SD Card"},
+		}
+	} else {
+		status["storage"] = object{"path": "/usb/", "name": "usb", "read_only": false}
+	}
+
+	return status
+}
+
+func (s *Simulator) jobV1(snap snapshot) object {
+	path := s.filePath()
+	directory := path[:strings.LastIndex(path, "/")]
+
+	return object{
+		"id":                   snap.JobID,
+		"state":                snap.State,
+		"progress":             snap.Progress,
+		"time_remaining":       snap.TimeRemaining,
+		"time_printing":        snap.TimePrinting,
+		"inaccurate_estimates": false,
+		"file": object{
+			"refs": object{
+				"icon":      "/thumb/s" + path,
+				"thumbnail": "/thumb/l" + path,
+				"download":  path,
+			},
+			"name":         path[strings.LastIndex(path, "/")+1:],
+			"display_name": s.model.JobFile,
+			"path":         directory,
+			"display_path": directory,
+			"size":         10262918,
+			"m_timestamp":  1706802615,
+			"meta": object{
+				"printer_model":        s.model.PrinterModel,
+				"layer_height":         0.2,
+				"filament_type":        snap.Material,
+				"estimated_print_time": snap.TimePrinting + snap.TimeRemaining,
+				"filament used [g]":    42.37,
+			},
+		},
+	}
+}
+
+func (s *Simulator) info() object {
+	info := object{
+		"nozzle_diameter":    s.model.NozzleDiameter,
+		"mmu":                s.options.MMU,
+		"serial":             s.model.Serial,
+		"hostname":           s.model.Hostname,
+		"min_extrusion_temp": 170,
+	}
+
+	if s.model.Board == "einsy" {
+		info["name"] = "Simulated " + s.model.Type
+		info["location"] = "Simulator"
+		info["farm_mode"] = false
+		info["network_error_chime"] = false
+		info["port"] = 0
+	}
+
+	return info
+}
+
+func (s *Simulator) storage() []object {
+	if s.model.Board == "einsy" {
+		return []object{
+			{
+				"type": "LOCAL", "path": "/local", "available": true, "free_space": 27429449728.0, "total_space": 30323138560.0,
+				"read_only": false, "name": "PrusaLink gcodes", "print_files": 0, "system_files": 0,
+			},
+			{
+				"type": "SDCARD", "path": "/sdcard", "available": true, "read_only": true, "name": "SD Card",
+				"print_files": 20086729, "system_files": 75331741,
+			},
+		}
+	}
+
+	return []object{{"path": "/usb/", "name": "usb", "type": "USB", "read_only": false, "available": true}}
+}
+
+func (s *Simulator) printerProfiles() object {
+	return object{"profiles": []object{{
+		"color": "default", "current": true, "default": true,
+		"extruder":  object{"count": 1, "offsets": []int{0, 0}},
+		"heatedBed": true, "heatedChamber": true, "id": "_default", "model": "Original Prusa SLA", "name": "Default",
+		"projectExtensions": []string{".sl1"}, "resource": "/api/printerprofiles/_default",
+	}}}
+}
+
+// mmuSlot returns MMU slot numbered from 1 that is used by the job, 0 when no job is printed
+func (s *Simulator) mmuSlot(snap snapshot) int {
+	if !snap.Job {
+		return 0
+	}
+	return snap.JobID%mmuSlots + 1
+}
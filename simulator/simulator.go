@@ -0,0 +1,333 @@
+package simulator
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Step is one phase of the simulated job lifecycle
+type Step struct {
+	State    string        // printer state during the step - IDLE, PRINTING, PAUSED, FINISHED, STOPPED, ERROR or ATTENTION
+	Duration time.Duration // how long the step lasts
+}
+
+// Fault is an error injected into responses of the simulated printer
+type Fault struct {
+	Path        string  // path of the endpoint, e.g. /api/v1/status, or * for every endpoint
+	Status      int     // HTTP status code returned instead of the payload
+	Probability float64 // probability of the fault in range 0.0 - 1.0
+}
+
+// Options is a struct that configures the simulated printer
+type Options struct {
+	Model    string        // printer type of the simulated printer, see Models
+	Username string        // username for digest authentication, digest is disabled when password is empty
+	Password string        // password for digest authentication
+	APIKey   string        // value of X-Api-Key header, api key authentication is disabled when empty
+	Script   []Step        // job lifecycle that is repeated over and over, DefaultScript is used when empty
+	Faults   []Fault       // errors injected into responses
+	Latency  time.Duration // delay added to every response
+	MAC      string        // mac address used as hostname of syslog packets
+	MMU      bool          // printer has MMU3 with mmuSlots filament slots
+	Cameras  int           // number of cameras connected to PrusaLink, only einsy printers have cameras
+	Seed     int64         // seed of fault injection randomness
+	Now      func() time.Time
+}
+
+// Simulator is a simulated PrusaLink printer
+type Simulator struct {
+	model   Model
+	options Options
+	start   time.Time
+	random  *rand.Rand
+	mutex   sync.Mutex
+	nonces  map[string]time.Time // nonce of digest challenge -> time when it was last used
+}
+
+// snapshot is a state of the simulated printer in one moment
+type snapshot struct {
+	State         string
+	Job           bool
+	JobID         int
+	Progress      float64 // in percents
+	TimePrinting  float64 // in seconds
+	TimeRemaining float64 // in seconds
+	TempNozzle    float64
+	TargetNozzle  float64
+	TempBed       float64
+	TargetBed     float64
+	AxisX         float64
+	AxisY         float64
+	AxisZ         float64
+	FanHotend     float64 // in rpm
+	FanPrint      float64 // in rpm
+	Speed         float64 // in percents
+	Flow          float64 // in percents
+	Material      string
+	Uptime        time.Duration
+}
+
+const (
+	ambientTemp = 24.5
+	jobIDOffset = 100
+	mmuSlots    = 5
+	resinTank   = 200.0 // resin in the tank at the start of the job in ml
+	resinJob    = 42.5  // resin consumed by the simulated job in ml
+)
+
+var (
+	// DefaultScript is job lifecycle used when none is configured
+	DefaultScript = []Step{
+		{State: "IDLE", Duration: 30 * time.Second},
+		{State: "PRINTING", Duration: 10 * time.Minute},
+		{State: "PAUSED", Duration: time.Minute},
+		{State: "PRINTING", Duration: 5 * time.Minute},
+		{State: "FINISHED", Duration: time.Minute},
+	}
+
+	jobStates = map[string]bool{
+		"PRINTING":  true,
+		"PAUSED":    true,
+		"FINISHED":  true,
+		"STOPPED":   true,
+		"ERROR":     true,
+		"ATTENTION": true,
+	}
+)
+
+// New returns a new simulated printer for the given options
+func New(options Options) (*Simulator, error) {
+	model, ok := GetModel(options.Model)
+	if !ok {
+		return nil, errors.New("unknown printer model " + options.Model + ", supported models are " + strings.Join(Models(), ", "))
+	}
+
+	if len(options.Script) == 0 {
+		options.Script = DefaultScript
+	}
+
+	for _, step := range options.Script {
+		if step.State != "IDLE" && !jobStates[step.State] {
+			return nil, errors.New("unknown state " + step.State + " in script")
+		}
+		if step.Duration <= 0 {
+			return nil, errors.New("duration of " + step.State + " step must be greater than 0")
+		}
+	}
+
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	if options.MAC == "" {
+		options.MAC = "10:9c:70:2c:da:08"
+	}
+
+	return &Simulator{
+		model:   model,
+		options: options,
+		start:   options.Now(),
+		random:  rand.New(rand.NewSource(options.Seed)),
+		nonces:  map[string]time.Time{},
+	}, nil
+}
+
+// Model returns the model of the simulated printer
+func (s *Simulator) Model() Model {
+	return s.model
+}
+
+// ParseScript parses job lifecycle from the format STATE:DURATION,STATE:DURATION - e.g. IDLE:30s,PRINTING:10m,FINISHED:1m
+func ParseScript(script string) ([]Step, error) {
+	var steps []Step
+	if script == "" {
+		return steps, nil
+	}
+
+	for _, part := range strings.Split(script, ",") {
+		state, duration, found := strings.Cut(strings.TrimSpace(part), ":")
+		if !found {
+			return steps, errors.New("step " + part + " is not in format STATE:DURATION")
+		}
+
+		parsed, err := time.ParseDuration(duration)
+		if err != nil {
+			return steps, err
+		}
+
+		steps = append(steps, Step{State: strings.ToUpper(state), Duration: parsed})
+	}
+
+	return steps, nil
+}
+
+// ParseFault parses fault from the format PATH=STATUS[@PROBABILITY] - e.g. /api/v1/status=500@0.5
+func ParseFault(fault string) (Fault, error) {
+	path, rest, found := strings.Cut(fault, "=")
+	if !found {
+		return Fault{}, errors.New("fault " + fault + " is not in format PATH=STATUS[@PROBABILITY]")
+	}
+
+	status, probability, found := strings.Cut(rest, "@")
+
+	parsedStatus, err := strconv.Atoi(status)
+	if err != nil {
+		return Fault{}, err
+	}
+
+	parsedProbability := 1.0
+	if found {
+		parsedProbability, err = strconv.ParseFloat(probability, 64)
+		if err != nil {
+			return Fault{}, err
+		}
+	}
+
+	return Fault{Path: path, Status: parsedStatus, Probability: parsedProbability}, nil
+}
+
+// snapshot returns the state of the simulated printer at the current time of its clock
+func (s *Simulator) snapshot() snapshot {
+	uptime := s.options.Now().Sub(s.start)
+
+	var loop time.Duration
+	var printing time.Duration
+	for _, step := range s.options.Script {
+		loop += step.Duration
+		if step.State == "PRINTING" {
+			printing += step.Duration
+		}
+	}
+
+	iteration := int(uptime / loop)
+	elapsed := uptime % loop
+
+	current := s.options.Script[0]
+	var printed time.Duration
+	for _, step := range s.options.Script {
+		current = step
+		if elapsed < step.Duration {
+			if step.State == "PRINTING" {
+				printed += elapsed
+			}
+			break
+		}
+		elapsed -= step.Duration
+		if step.State == "PRINTING" {
+			printed += step.Duration
+		}
+	}
+
+	snap := snapshot{
+		State:      current.State,
+		Job:        jobStates[current.State],
+		JobID:      jobIDOffset + iteration,
+		TempNozzle: ambientTemp,
+		TempBed:    ambientTemp,
+		Speed:      100,
+		Flow:       100,
+		Material:   "PLA",
+		Uptime:     uptime,
+		AxisX:      0,
+		AxisY:      0,
+		AxisZ:      5,
+	}
+
+	if printing > 0 {
+		snap.Progress = math.Round(float64(printed)/float64(printing)*1000) / 10
+	}
+
+	if current.State == "FINISHED" {
+		snap.Progress = 100
+	}
+
+	snap.TimePrinting = math.Floor(printed.Seconds())
+	snap.TimeRemaining = math.Floor((printing - printed).Seconds())
+
+	if current.State == "PRINTING" || current.State == "PAUSED" {
+		snap.TargetNozzle = s.model.NozzleTarget
+		snap.TargetBed = s.model.BedTarget
+		snap.TempNozzle = s.model.NozzleTarget - 0.4
+		snap.TempBed = s.model.BedTarget + 0.1
+		snap.AxisZ = math.Round(snap.Progress*0.4*100) / 100
+		snap.FanHotend = 4080
+	}
+
+	if current.State == "PRINTING" {
+		snap.AxisX = math.Round((125+50*math.Sin(snap.TimePrinting/7))*100) / 100
+		snap.AxisY = math.Round((105+50*math.Cos(snap.TimePrinting/5))*100) / 100
+		snap.FanPrint = 5200
+	}
+
+	return snap
+}
+
+// fault returns the injected status code for the given path or 0 if there is no fault
+func (s *Simulator) fault(path string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, fault := range s.options.Faults {
+		if fault.Path != "*" && fault.Path != path {
+			continue
+		}
+		if s.random.Float64() < fault.Probability {
+			return fault.Status
+		}
+	}
+
+	return 0
+}
+
+// ServeHTTP implements http.Handler and serves PrusaLink API of the simulated printer
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.options.Latency > 0 {
+		time.Sleep(s.options.Latency)
+	}
+
+	// root is used by the exporter for probing and is accessible without authentication same as the web UI of real printers
+	if r.URL.Path == "/" {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body>PrusaLink " + s.model.Type + "</body></html>"))
+		return
+	}
+
+	if !s.authorized(w, r) {
+		return
+	}
+
+	if status := s.fault(r.URL.Path); status != 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/thumb/") && s.model.Board != "sl" {
+		writeThumbnail(w)
+		return
+	}
+
+	if s.model.Board == "einsy" && strings.HasPrefix(r.URL.Path, "/api/v1/cameras/") && strings.HasSuffix(r.URL.Path, "/snap") {
+		s.writeSnapshot(w, r)
+		return
+	}
+
+	payload, status := s.payload(r.URL.Path, s.snapshot())
+
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+
+	if payload == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	writeJSON(w, payload)
+}
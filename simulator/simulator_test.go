@@ -0,0 +1,143 @@
+package simulator
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/icholy/digest"
+)
+
+// newServer starts the simulated printer of the model with digest authentication, its clock stands at now
+func newServer(t *testing.T, model string, now *time.Time) (*Simulator, *httptest.Server) {
+	t.Helper()
+
+	printer, err := New(Options{Model: model, Username: "maker", Password: "secret", Now: func() time.Time { return *now }})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(printer)
+	t.Cleanup(server.Close)
+	return printer, server
+}
+
+func TestModels(t *testing.T) {
+	for _, model := range Models() {
+		t.Run(model, func(t *testing.T) {
+			now := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+			printer, server := newServer(t, model, &now)
+			now = now.Add(5 * time.Minute) // default script prints after 30 seconds of idle
+
+			client := &http.Client{Transport: &digest.Transport{Username: "maker", Password: "secret"}}
+
+			paths := []string{"/api/version", "/api/printer", "/api/job", "/api/files"}
+			if printer.Model().Board != "sl" {
+				paths = append(paths, "/api/v1/status", "/api/v1/info", "/api/v1/job")
+			}
+
+			for _, path := range paths {
+				response, err := client.Get(server.URL + path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var payload map[string]any
+				err = json.NewDecoder(response.Body).Decode(&payload)
+				response.Body.Close()
+				if response.StatusCode != http.StatusOK || err != nil {
+					t.Errorf("%s returned %d, %v", path, response.StatusCode, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDigest(t *testing.T) {
+	now := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+	printer, server := newServer(t, "MK4", &now)
+
+	response, err := http.Get(server.URL + "/api/version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusUnauthorized || !digest.IsDigest(response.Header.Get("WWW-Authenticate")) {
+		t.Errorf("request without credentials returned %d with challenge %q", response.StatusCode, response.Header.Get("WWW-Authenticate"))
+	}
+
+	client := &http.Client{Transport: &digest.Transport{Username: "maker", Password: "wrong"}}
+	response, err = client.Get(server.URL + "/api/version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("request with wrong password returned %d", response.StatusCode)
+	}
+
+	// clients that never answer the challenge do not grow nonces over the limit
+	for i := 0; i < maxNonces+10; i++ {
+		response, err := http.Get(server.URL + "/api/version")
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+	if len(printer.nonces) != maxNonces {
+		t.Errorf("simulator remembers %d nonces, want %d", len(printer.nonces), maxNonces)
+	}
+
+	now = now.Add(nonceLifetime + time.Second)
+	printer.newNonce()
+	if len(printer.nonces) != 1 {
+		t.Errorf("simulator remembers %d nonces after they expired, want 1", len(printer.nonces))
+	}
+}
+
+func TestEmitSyslog(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	printer, err := New(Options{Model: "XL", MAC: "10:9c:70:2c:da:12"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	errs := make(chan error, 1)
+	go func() { errs <- printer.EmitSyslog(listener.LocalAddr().String(), time.Hour, stop) }()
+
+	listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buffer := make([]byte, 65536)
+	n, _, err := listener.ReadFrom(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(stop)
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+
+	packet := string(buffer[:n])
+	for _, want := range []string{"<14>1 - 10:9c:70:2c:da:12 buddy", "buddy_bom v=" + strconv.Itoa(printer.Model().BuddyBom) + "i", "dwarf_board_temp,n=4 v=40i"} {
+		if !strings.Contains(packet, want) {
+			t.Errorf("syslog packet does not contain %s", want)
+		}
+	}
+
+	einsy, err := New(Options{Model: "I3MK3S"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := einsy.SyslogMessage(); err == nil {
+		t.Error("einsy printer sends syslog metrics")
+	}
+}
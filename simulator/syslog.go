@@ -0,0 +1,111 @@
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// SyslogMessage returns syslog message with metrics of the simulated printer in the same format as buddy firmware sends them
+func (s *Simulator) SyslogMessage() (string, error) {
+	if s.model.Board != "buddy" {
+		return "", errors.New("only buddy printers send syslog metrics, " + s.model.Type + " is " + s.model.Board)
+	}
+
+	snap := s.snapshot()
+	timestamp := snap.Uptime.Microseconds()
+
+	isPrinting := 0
+	if snap.State == "PRINTING" {
+		isPrinting = 1
+	}
+
+	lines := []string{
+		fmt.Sprintf("temp_noz v=%f", snap.TempNozzle),
+		fmt.Sprintf("ttemp_noz v=%di", int(snap.TargetNozzle)),
+		fmt.Sprintf("temp_bed v=%f", snap.TempBed),
+		fmt.Sprintf("ttemp_bed v=%di", int(snap.TargetBed)),
+		fmt.Sprintf("pos_x v=%f", snap.AxisX),
+		fmt.Sprintf("pos_y v=%f", snap.AxisY),
+		fmt.Sprintf("pos_z v=%f", snap.AxisZ),
+		fmt.Sprintf("fan_speed v=%di", int(snap.FanPrint/5200*255)),
+		fmt.Sprintf("fan_hbr_speed v=%di", int(snap.FanHotend/4080*255)),
+		fmt.Sprintf("is_printing v=%di", isPrinting),
+		"cpu_usage v=17i",
+		"heap free=63532i,total=89636i",
+		"volt_bed v=24.206451",
+		"curr_inp v=0.805854",
+		"temp_mcu v=45i",
+		"temp_brd v=37.720589",
+		fmt.Sprintf("buddy_bom v=%di", s.model.BuddyBom),
+		fmt.Sprintf("buddy_revision v=%di", s.model.BuddyRevision),
+		fmt.Sprintf("fw_version v=\"%s\"", s.model.Firmware),
+		fmt.Sprintf("filament v=\"%s\"", snap.Material),
+	}
+
+	if snap.Job {
+		lines = append(lines, fmt.Sprintf("print_filename v=\"%s\"", shortName(s.model.JobFile)))
+	}
+
+	if s.options.MMU {
+		// MMU protocol numbers slots from 0 in hex, printer polls state of the current command by Q0
+		lines = append(lines, "mmu_comm v=\">Q0*c6.\"")
+		if slot := s.mmuSlot(snap); slot > 0 {
+			lines = append(lines, fmt.Sprintf("mmu_comm v=\"<T%x F*2b.\"", slot-1))
+		}
+	}
+
+	if s.model.Loadcell {
+		lines = append(lines, "loadcell_value v=-12.5")
+	}
+
+	if s.model.Tools > 1 {
+		lines = append(lines, "active_extruder v=0i")
+		for tool := 0; tool < s.model.Tools; tool++ {
+			lines = append(lines,
+				"dwarf_board_temp,n="+strconv.Itoa(tool)+" v=40i",
+				"dwarf_mcu_temp,n="+strconv.Itoa(tool)+" v=37i")
+		}
+	}
+
+	for i, line := range lines {
+		lines[i] = line + " " + strconv.FormatInt(timestamp, 10)
+	}
+
+	return "<14>1 - " + s.options.MAC + " buddy - - - " + strings.Join(lines, "\n"), nil
+}
+
+// EmitSyslog sends syslog metrics of the simulated printer to the target every interval until stop is closed
+func (s *Simulator) EmitSyslog(target string, interval time.Duration, stop <-chan struct{}) error {
+	conn, err := net.Dial("udp", target)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		message, err := s.SyslogMessage()
+		if err != nil {
+			return err
+		}
+
+		// listener can be down or not started yet, UDP does not care so neither does the simulator
+		if _, err := conn.Write([]byte(message)); err != nil {
+			log.Debug().Msg("Error sending simulated syslog metrics " + err.Error())
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
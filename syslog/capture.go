@@ -0,0 +1,134 @@
+package syslog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Packet is a single syslog datagram recorded by Capture
+type Packet struct {
+	Time   time.Time `json:"time"`   // arrival time of the packet
+	Source string    `json:"source"` // address of the printer that sent the packet
+	Data   string    `json:"data"`   // raw syslog message as received
+}
+
+// Capture listens for syslog packets at listenUDP and writes every received datagram with its arrival time to the file at path.
+// Packets are stored one JSON object per line so captures can be inspected, edited and concatenated with ordinary tools.
+// Capture runs until the listener fails or count packets were written - count lower than 1 means no limit.
+func Capture(listenUDP string, path string, count int) error {
+	conn, err := net.ListenPacket("udp", listenUDP)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	log.Info().Msg("Capturing syslog packets from " + listenUDP + " into " + path)
+	return capturePackets(conn, path, count)
+}
+
+// capturePackets writes packets received by conn to the file at path, conn is already listening so senders can not miss it
+func capturePackets(conn net.PacketConn, path string, count int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	buffer := make([]byte, 65536)
+
+	for written := 0; count < 1 || written < count; written++ {
+		n, addr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			return err
+		}
+
+		packet := Packet{
+			Time:   time.Now(),
+			Source: addr.String(),
+			Data:   string(buffer[:n]),
+		}
+
+		if err := encoder.Encode(packet); err != nil {
+			return err
+		}
+
+		// flushing after every packet so the capture is usable even when the process is killed
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+
+		log.Trace().Msg("Captured packet from " + packet.Source)
+	}
+
+	return nil
+}
+
+// ReadCapture reads all packets from a capture file created by Capture
+func ReadCapture(path string) ([]Packet, error) {
+	var packets []Packet
+
+	file, err := os.Open(path)
+	if err != nil {
+		return packets, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	for {
+		var packet Packet
+		err := decoder.Decode(&packet)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return packets, err
+		}
+		packets = append(packets, packet)
+	}
+
+	return packets, nil
+}
+
+// Replay sends packets from the capture file at path to the syslog listener at target.
+// Delays between packets are kept as they were captured and divided by speed, so 2 replays twice as fast.
+// Speed 0 or lower sends all packets without any delay.
+func Replay(path string, target string, speed float64) error {
+	packets, err := ReadCapture(path)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.Dial("udp", target)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	log.Info().Msgf("Replaying %d packets from %s to %s", len(packets), path, target)
+
+	for i, packet := range packets {
+		if i > 0 && speed > 0 {
+			delay := packet.Time.Sub(packets[i-1].Time)
+			if delay > 0 {
+				time.Sleep(time.Duration(float64(delay) / speed))
+			}
+		}
+
+		if _, err := conn.Write([]byte(packet.Data)); err != nil {
+			return err
+		}
+
+		log.Trace().Msgf("Replayed packet %d originally from %s", i, packet.Source)
+	}
+
+	return nil
+}
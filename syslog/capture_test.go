@@ -0,0 +1,105 @@
+package syslog
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCaptureReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.jsonl")
+	messages := []string{
+		"<14>1 - 10:9c:70:2c:da:12 buddy - - - temp_noz v=214.6 1000",
+		"<14>1 - 10:9c:70:2c:da:12 buddy - - - temp_bed v=60.1 2000",
+		"<14>1 - 10:9c:70:2c:da:12 buddy - - - is_printing v=1i 3000",
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	captured := make(chan error, 1)
+	go func() { captured <- capturePackets(conn, path, len(messages)) }()
+
+	sender, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+	for _, message := range messages {
+		if _, err := sender.Write([]byte(message)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case err := <-captured:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("capture did not receive all packets")
+	}
+
+	packets, err := ReadCapture(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packets) != len(messages) {
+		t.Fatalf("read %d packets, want %d", len(packets), len(messages))
+	}
+	for i, packet := range packets {
+		if packet.Data != messages[i] || packet.Source != sender.LocalAddr().String() || packet.Time.IsZero() {
+			t.Errorf("packet %d is %+v, want %s from %s", i, packet, messages[i], sender.LocalAddr())
+		}
+	}
+
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	if err := Replay(path, listener.LocalAddr().String(), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buffer := make([]byte, 65536)
+	for i, message := range messages {
+		n, _, err := listener.ReadFrom(buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buffer[:n]) != message {
+			t.Errorf("replayed packet %d is %s, want %s", i, buffer[:n], message)
+		}
+	}
+}
+
+func TestReadCaptureMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.jsonl")
+	data := `{"time":"2024-02-01T12:00:00Z","source":"192.168.20.12:514","data":"temp_noz v=214.6 1000"}` + "\nnot a packet\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	packets, err := ReadCapture(path)
+	if err == nil {
+		t.Error("malformed capture is read without error")
+	}
+	if len(packets) != 1 || packets[0].Source != "192.168.20.12:514" {
+		t.Errorf("packets before the malformed line are %+v", packets)
+	}
+
+	if err := Replay(path, "127.0.0.1:9", 0); err == nil {
+		t.Error("malformed capture is replayed")
+	}
+	if _, err := ReadCapture(filepath.Join(t.TempDir(), "missing.jsonl")); err == nil {
+		t.Error("missing capture is read without error")
+	}
+}
@@ -10,8 +10,18 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// milliamperes converts currents sent by the firmware in amperes to milliamperes of prusa_current, power of rails
+// is computed from the sent amperes. Dwarfs send current of their heater in milliamperes already.
+const milliamperes = 1000
+
 var (
 	ttl = 60
+
+	// networkInterfaces are readable names of network interfaces reported in syslog metrics
+	networkInterfaces = map[string]string{
+		"esp": "wifi",
+		"eth": "ethernet",
+	}
 )
 
 // Collect is a function that collects all the metrics
@@ -19,12 +29,14 @@ func (collector *Collector) Collect(ch chan<- prometheus.Metric) {
 	defer mutex.RUnlock()
 	log.Debug().Msgf("Collecting syslog metrics")
 
+	updateCustomLabels()
+
 	mutex.RLock()
 
 	for mac, v := range syslogMetrics {
 		log.Trace().Msgf("Loading data for %s", mac)
 
-		ip := strings.Split(v["ip"]["value"], ":")[0]
+		ip := getHost(v["ip"]["value"])
 
 		alive := false
 
@@ -46,12 +58,37 @@ func (collector *Collector) Collect(ch chan<- prometheus.Metric) {
 		}
 		ch <- prometheus.MustNewConstMetric(collector.printerSyslogUp, prometheus.GaugeValue, prusalink.BoolToFloat(alive), getLabels(mac, ip, []string{})...)
 
+		activeExtruder := v["active_extruder"]["value"] // tool labels of dwarf metrics are compared to it
+
 		if alive {
+			if stats := mmuStatsByMac[mac]; stats != nil {
+				collector.collectMMU(ch, mac, ip, stats)
+			}
+
+			for rail, power := range getRailPower(v) {
+				ch <- prometheus.MustNewConstMetric(collector.printerRailPower, prometheus.GaugeValue, power, getLabels(mac, ip, []string{rail})...)
+			}
+
+			if recorder := meshesByMac[mac]; recorder != nil {
+				if mesh, ok := recorder.latest(); ok {
+					collector.collectMesh(ch, mac, ip, mesh)
+				}
+			}
+
+			if health := stepperByMac[mac]; health != nil {
+				collector.collectStepper(ch, mac, ip, health)
+			}
+
+			if history := qualityByMac[mac]; history != nil {
+				collector.collectQuality(ch, getQualityReport(mac, ip, history))
+			}
+
 			for k, v := range v {
 				var (
 					collectorItem *prometheus.Desc
 					labels        = []string{}
 					suffix        string
+					tool          string // label of temperatures and currents of XL heads
 					valueParsed   float64
 					valueKey      = "value" // mostly its value
 				)
@@ -88,8 +125,13 @@ func (collector *Collector) Collect(ch chan<- prometheus.Metric) {
 					labels = []string{splittedName[1] + suffix}
 					collectorItem = collector.printerTemp
 				case "dwarf_board_temp":
+					if activeExtruder != "" {
+						ch <- prometheus.MustNewConstMetric(collector.printerDwarfActive, prometheus.GaugeValue,
+							prusalink.BoolToFloat(strconv.Itoa(length) == activeExtruder), getLabels(mac, ip, []string{strconv.Itoa(length)})...)
+					}
 					fallthrough
 				case "dwarf_mcu_temp":
+					tool = strconv.Itoa(length)
 					fallthrough
 				case "dwarfs_mcu_temp":
 					fallthrough
@@ -125,7 +167,7 @@ func (collector *Collector) Collect(ch chan<- prometheus.Metric) {
 						continue // Skip to next iteration if value parsing fails
 					}
 
-					ch <- prometheus.MustNewConstMetric(collector.printerNetworkOut, prometheus.CounterValue, valueParsed, getLabels(mac, ip, []string{splittedName[0]})...)
+					collector.collectNetwork(ch, collector.printerNetworkOut, collector.printerNetworkSent, valueParsed, mac, ip, splittedName[0])
 					continue
 				case "esp_in":
 					fallthrough
@@ -136,7 +178,7 @@ func (collector *Collector) Collect(ch chan<- prometheus.Metric) {
 						continue // Skip to next iteration if value parsing fails
 					}
 
-					ch <- prometheus.MustNewConstMetric(collector.printerNetworkIn, prometheus.CounterValue, valueParsed, getLabels(mac, ip, []string{splittedName[0]})...)
+					collector.collectNetwork(ch, collector.printerNetworkIn, collector.printerNetworkReceived, valueParsed, mac, ip, splittedName[0])
 					continue
 				case "24VVoltage":
 					fallthrough
@@ -177,6 +219,7 @@ func (collector *Collector) Collect(ch chan<- prometheus.Metric) {
 					labels = []string{"", splittedName[0] + suffix}
 					collectorItem = collector.printerCurrent
 				case "dwarf_heat_curr":
+					tool = strconv.Itoa(length)
 					labels = []string{"", splittedName[0] + "_" + splittedName[1] + suffix}
 					collectorItem = collector.printerCurrent
 				case "tmc_sg_x":
@@ -265,14 +308,14 @@ func (collector *Collector) Collect(ch chan<- prometheus.Metric) {
 
 						continue // Skip to next iteration if value parsing fails
 					}
-					ch <- prometheus.MustNewConstMetric(collector.printerHeapFree, prometheus.GaugeValue, valueParsed, getLabels(mac, ip, []string{})...)
+					collector.names.Emit(ch, collector.printerHeapFree, prometheus.GaugeValue, valueParsed, getLabels(mac, ip, []string{})...)
 					valueParsed, err = strconv.ParseFloat(v["total"], 64)
 					if err != nil {
 						log.Error().Msgf("Error parsing value for metric %s: %s", k, err)
 
 						continue // Skip to next iteration if value parsing fails
 					}
-					ch <- prometheus.MustNewConstMetric(collector.printerHeapTotal, prometheus.GaugeValue, valueParsed, getLabels(mac, ip, []string{})...)
+					collector.names.Emit(ch, collector.printerHeapTotal, prometheus.GaugeValue, valueParsed, getLabels(mac, ip, []string{})...)
 					continue
 				case "print_fan_act":
 					labels = []string{"print"}
@@ -501,12 +544,106 @@ func (collector *Collector) Collect(ch chan<- prometheus.Metric) {
 				}
 
 				if collectorItem == collector.printerCurrent && !strings.Contains(k, "dwarf") {
-					valueParsed = valueParsed * 1000 // firmware uses MODBUS_CURRENT_REGISTERS_SCALE = 1000 I'm upscaling this value ... just a little workaround
+					valueParsed = valueParsed * milliamperes
+				}
+				if collectorItem == collector.printerTemp || collectorItem == collector.printerCurrent {
+					labels = append(labels, tool)
 				}
 
-				printerMetric := prometheus.MustNewConstMetric(collectorItem, prometheus.GaugeValue, valueParsed, getLabels(mac, ip, labels)...)
-				ch <- printerMetric
+				collector.names.Emit(ch, collectorItem, prometheus.GaugeValue, valueParsed, getLabels(mac, ip, labels)...)
 			}
 		}
 	}
 }
+
+// collectNetwork collects bytes sent or received by esp (Wi-Fi) or eth interface, v2 name has interface label with readable name
+func (collector *Collector) collectNetwork(ch chan<- prometheus.Metric, legacy *prometheus.Desc, v2 *prometheus.Desc, value float64, mac string, ip string, device string) {
+	if collector.names.Legacy() {
+		ch <- prometheus.MustNewConstMetric(legacy, prometheus.CounterValue, value, getLabels(mac, ip, []string{device})...)
+	}
+
+	if collector.names.V2() {
+		name := networkInterfaces[device]
+		if name == "" {
+			name = device
+		}
+		ch <- prometheus.MustNewConstMetric(v2, prometheus.CounterValue, value, getLabels(mac, ip, []string{name})...)
+	}
+}
+
+// collectMMU collects counters parsed from mmu_comm messages
+func (collector *Collector) collectMMU(ch chan<- prometheus.Metric, mac string, ip string, stats *mmuStats) {
+	currentSlot, err := strconv.ParseFloat(stats.currentSlot, 64)
+	if err != nil {
+		currentSlot = 0
+	}
+	ch <- prometheus.MustNewConstMetric(collector.printerMMUCurrentSlot, prometheus.GaugeValue, currentSlot, getLabels(mac, ip, []string{})...)
+
+	for slot, results := range stats.loads {
+		for result, count := range results {
+			ch <- prometheus.MustNewConstMetric(collector.printerMMULoads, prometheus.CounterValue, count, getLabels(mac, ip, []string{slot, result})...)
+		}
+	}
+
+	for slot, results := range stats.unloads {
+		for result, count := range results {
+			ch <- prometheus.MustNewConstMetric(collector.printerMMUUnloads, prometheus.CounterValue, count, getLabels(mac, ip, []string{slot, result})...)
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(collector.printerMMUCommErrors, prometheus.CounterValue, stats.commErrors, getLabels(mac, ip, []string{})...)
+	ch <- prometheus.MustNewConstMetric(collector.printerMMUCommRetries, prometheus.CounterValue, stats.retries, getLabels(mac, ip, []string{})...)
+}
+
+// collectMesh collects points of the latest bed mesh, Z is converted from mm to meters
+func (collector *Collector) collectMesh(ch chan<- prometheus.Metric, mac string, ip string, mesh Mesh) {
+	ch <- prometheus.MustNewConstMetric(collector.printerBedMeshPoints, prometheus.GaugeValue, float64(len(mesh.Points)), getLabels(mac, ip, []string{})...)
+
+	for _, point := range mesh.Points {
+		ch <- prometheus.MustNewConstMetric(collector.printerBedMeshZ, prometheus.GaugeValue, point.Z*0.001,
+			getLabels(mac, ip, []string{strconv.FormatFloat(point.X, 'f', -1, 64), strconv.FormatFloat(point.Y, 'f', -1, 64)})...)
+	}
+}
+
+// collectStepper collects StallGuard distribution, crashes and health score of steppers by axis
+func (collector *Collector) collectStepper(ch chan<- prometheus.Metric, mac string, ip string, health *stepperHealth) {
+	for axis, history := range health.stallGuard {
+		ch <- prometheus.MustNewConstHistogram(collector.printerStepperStallGuard, history.count, history.sum, history.buckets, getLabels(mac, ip, []string{axis})...)
+	}
+
+	for _, stepper := range getStepperAxis(health, time.Now()) {
+		labels := getLabels(mac, ip, []string{stepper.axis})
+		if stepper.crashes > 0 {
+			ch <- prometheus.MustNewConstMetric(collector.printerStepperCrashes, prometheus.CounterValue, stepper.crashes, labels...)
+		}
+		if stepper.healthScore != nil {
+			ch <- prometheus.MustNewConstMetric(collector.printerStepperBaseline, prometheus.GaugeValue, *stepper.baseline, labels...)
+			ch <- prometheus.MustNewConstMetric(collector.printerStepperDrift, prometheus.GaugeValue, *stepper.drift, labels...)
+			ch <- prometheus.MustNewConstMetric(collector.printerStepperHealth, prometheus.GaugeValue, *stepper.healthScore, labels...)
+		}
+	}
+}
+
+// collectQuality collects print quality analytics of the report, lengths are converted from mm to meters
+func (collector *Collector) collectQuality(ch chan<- prometheus.Metric, report QualityReport) {
+	optional := func(desc *prometheus.Desc, value *float64, scale float64) {
+		if value != nil {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, *value*scale, getLabels(report.MAC, report.IP, []string{})...)
+		}
+	}
+
+	optional(collector.printerQualityMeshDeviation, report.BedMeshDeviation, 0.001)
+	optional(collector.printerQualityProbeSpread, report.ProbeSpread, 0.001)
+	optional(collector.printerQualityProbeAnalysis, report.ProbeAnalysisOk, 1)
+	optional(collector.printerQualityLoadcellNoise, report.LoadcellNoise, 1)
+
+	for axis, repeatability := range report.HomingRepeatability {
+		ch <- prometheus.MustNewConstMetric(collector.printerQualityHoming, prometheus.GaugeValue, repeatability*0.001, getLabels(report.MAC, report.IP, []string{axis})...)
+	}
+
+	for tool, drift := range report.ToolOffsetDrift {
+		for axis, value := range drift {
+			ch <- prometheus.MustNewConstMetric(collector.printerQualityToolDrift, prometheus.GaugeValue, value*0.001, getLabels(report.MAC, report.IP, []string{tool, axis})...)
+		}
+	}
+}
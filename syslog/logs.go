@@ -6,7 +6,6 @@ import (
 	"os"
 	"path"
 	"strconv"
-	"strings"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -62,7 +61,7 @@ func HandleLogs(listenUDP string, directory string, filename string, maxSize int
 			log.Trace().Msg(fmt.Sprintf("%v", logParts))
 			syslogLogger.Info().
 				Str("app_name", logParts["app_name"].(string)).
-				Str("client", strings.Split(logParts["client"].(string), ":")[0]).
+				Str("client", getHost(logParts["client"].(string))).
 				Str("hostname", logParts["hostname"].(string)).
 				Str("priority", strconv.Itoa(logParts["priority"].(int))).
 				Str("proc_id", logParts["proc_id"].(string)).
@@ -0,0 +1,195 @@
+package syslog
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Mesh is one bed mesh measurement, coordinates and Z are in mm as sent by firmware
+type Mesh struct {
+	Time   time.Time   `json:"time"` // time of the first point of the mesh
+	Points []MeshPoint `json:"points"`
+}
+
+// MeshPoint is one probed point of bed mesh
+type MeshPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// PrinterMeshes are stored bed meshes of one printer, the latest mesh is the last one
+type PrinterMeshes struct {
+	MAC    string            `json:"mac"`
+	IP     string            `json:"ip"`
+	Meshes []Mesh            `json:"meshes"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// meshRecorder assembles probe_z points of one printer into meshes
+type meshRecorder struct {
+	meshes   []Mesh          // finished meshes, the oldest is dropped when there are more than meshHistory of them
+	current  Mesh            // mesh that is being probed
+	probed   map[string]bool // "x,y" of points in the current mesh
+	printing bool            // last is_printing, new mesh starts with every print
+}
+
+var (
+	// meshHistory is number of meshes stored per printer including the current one
+	meshHistory = 10
+
+	// meshesByMac contains bed meshes of printers, it is guarded by mutex together with syslogMetrics
+	meshesByMac = map[string]*meshRecorder{}
+
+	meshProbeZ     = regexp.MustCompile(`^probe_z x=([-\d\.]+),y=([-\d\.]+),v=([-\d\.]+)`)
+	meshIsPrinting = regexp.MustCompile(`^is_printing v=(\d+)i`)
+)
+
+// finish moves the current mesh to finished meshes and starts a new one
+func (recorder *meshRecorder) finish() {
+	if len(recorder.current.Points) > 0 {
+		recorder.meshes = append(recorder.meshes, recorder.current)
+		if len(recorder.meshes) >= meshHistory {
+			recorder.meshes = recorder.meshes[len(recorder.meshes)-meshHistory+1:]
+		}
+	}
+	recorder.current = Mesh{}
+	recorder.probed = map[string]bool{}
+}
+
+// all returns finished meshes followed by the current one when it has any points
+func (recorder *meshRecorder) all() []Mesh {
+	meshes := append([]Mesh{}, recorder.meshes...)
+	if len(recorder.current.Points) > 0 {
+		meshes = append(meshes, recorder.current)
+	}
+	return meshes
+}
+
+// latest returns the current mesh or the last finished one when the current has no points yet
+func (recorder *meshRecorder) latest() (Mesh, bool) {
+	meshes := recorder.all()
+	if len(meshes) == 0 {
+		return Mesh{}, false
+	}
+	return meshes[len(meshes)-1], true
+}
+
+// recordMesh updates bed meshes of the printer with one metric message. A new mesh starts when print starts
+// or when already probed point of the current mesh is probed again.
+func recordMesh(mac string, message string, received time.Time) {
+	recorder := meshesByMac[mac]
+	if recorder == nil {
+		recorder = &meshRecorder{probed: map[string]bool{}}
+		meshesByMac[mac] = recorder
+	}
+
+	if match := meshIsPrinting.FindStringSubmatch(message); match != nil {
+		printing := match[1] != "0"
+		if printing && !recorder.printing {
+			recorder.finish()
+		}
+		recorder.printing = printing
+		return
+	}
+
+	match := meshProbeZ.FindStringSubmatch(message)
+	if match == nil {
+		return
+	}
+
+	var point MeshPoint
+	var err error
+	for i, value := range []*float64{&point.X, &point.Y, &point.Z} {
+		if *value, err = strconv.ParseFloat(match[i+1], 64); err != nil {
+			log.Error().Msg("Error parsing probe_z value " + match[i+1] + " - " + err.Error())
+			return
+		}
+	}
+
+	key := match[1] + "," + match[2]
+	if recorder.probed[key] {
+		recorder.finish()
+	}
+	if len(recorder.current.Points) == 0 {
+		recorder.current.Time = received
+	}
+	recorder.probed[key] = true
+	recorder.current.Points = append(recorder.current.Points, point)
+}
+
+// GetMeshes returns stored bed meshes of all printers that sent probe points, sorted by MAC address
+func GetMeshes() []PrinterMeshes {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	printers := []PrinterMeshes{}
+	for mac, recorder := range meshesByMac {
+		meshes := recorder.all()
+		if len(meshes) == 0 {
+			continue
+		}
+		printers = append(printers, PrinterMeshes{MAC: mac, IP: getIP(mac), Meshes: meshes, Labels: getCustomLabels(getIP(mac))})
+	}
+
+	sort.Slice(printers, func(i, j int) bool {
+		return printers[i].MAC < printers[j].MAC
+	})
+
+	return printers
+}
+
+// MeshHandler returns handler of bed meshes at the prefix - meshes of all printers at prefix
+// and meshes of one printer at prefix/<mac or ip>
+func MeshHandler(prefix string) http.Handler {
+	return printerHandler(prefix, GetMeshes, func(printer PrinterMeshes) []string {
+		return []string{printer.MAC, printer.IP}
+	})
+}
+
+// getIP returns IP address of the printer with the MAC address, it has to be called with mutex locked
+func getIP(mac string) string {
+	if syslogMetrics[mac] == nil {
+		return ""
+	}
+	return getHost(syslogMetrics[mac]["ip"]["value"])
+}
+
+// printerHandler returns JSON handler at the prefix - list of all values at prefix and the value
+// with matching identifier at prefix/<id>, 404 when there is no such value
+func printerHandler[T any](prefix string, list func() []T, ids func(T) []string) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	return http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Trim(r.URL.Path, "/")
+		values := list()
+
+		var response any = values
+		if id != "" {
+			response = nil
+			for _, value := range values {
+				for _, valueID := range ids(value) {
+					if valueID == id {
+						response = value
+					}
+				}
+			}
+			if response == nil {
+				http.NotFound(w, r)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Error().Msg("Error while writing JSON response - " + err.Error())
+		}
+	}))
+}
@@ -0,0 +1,74 @@
+package syslog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMeshHandler(t *testing.T) {
+	loadCapture(t, "MK4", "XL")
+
+	server := httptest.NewServer(MeshHandler("/mesh"))
+	defer server.Close()
+
+	var printers []PrinterMeshes
+	response, err := http.Get(server.URL + "/mesh/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.NewDecoder(response.Body).Decode(&printers); err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if len(printers) != 1 || printers[0].IP != "192.168.20.13" {
+		t.Fatalf("got meshes of %+v, want only XL", printers)
+	}
+
+	// the second print starts a new mesh with the same points
+	if len(printers[0].Meshes) != 2 {
+		t.Fatalf("got %d meshes, want 2", len(printers[0].Meshes))
+	}
+
+	for i, mesh := range printers[0].Meshes {
+		if len(mesh.Points) != 6 {
+			t.Errorf("mesh %d has %d points, want 6", i, len(mesh.Points))
+		}
+	}
+
+	if got := printers[0].Meshes[1].Points[0]; got != (MeshPoint{X: 30, Y: 30, Z: 0.025}) {
+		t.Errorf("got first point %+v of the latest mesh, want 30, 30, 0.025", got)
+	}
+
+	response, err = http.Get(server.URL + "/mesh/192.168.20.12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("got status %d for printer without mesh, want 404", response.StatusCode)
+	}
+}
+
+func TestMeshHistory(t *testing.T) {
+	loadCapture(t)
+
+	// every repeated point starts a new mesh, only meshHistory of them are kept
+	for i := 0; i < meshHistory+5; i++ {
+		recordMesh("mac", "probe_z x=30.000,y=30.000,v="+strconv.Itoa(i), time.Now())
+	}
+
+	meshes := meshesByMac["mac"].all()
+	if len(meshes) != meshHistory {
+		t.Fatalf("got %d meshes, want %d", len(meshes), meshHistory)
+	}
+
+	if got, want := meshes[len(meshes)-1].Points[0].Z, float64(meshHistory+4); got != want {
+		t.Errorf("got Z %v of the latest mesh, want %v", got, want)
+	}
+}
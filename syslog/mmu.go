@@ -0,0 +1,104 @@
+package syslog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// mmuStats are counters of MMU events parsed from mmu_comm messages of one printer
+type mmuStats struct {
+	currentSlot string                        // slot with filament in the nozzle, empty when unknown
+	loads       map[string]map[string]float64 // slot -> result -> count
+	unloads     map[string]map[string]float64 // slot -> result -> count
+	commErrors  float64
+	retries     float64
+	last        string // last command and state, repeated responses to Q0 are counted only once
+}
+
+var (
+	// mmuStatsByMac contains MMU counters of printers, it is guarded by mutex together with syslogMetrics
+	mmuStatsByMac = map[string]*mmuStats{}
+
+	mmuCommValue = regexp.MustCompile(`^mmu_comm v="(.*)"`)
+
+	// mmuResponse matches MMU protocol messages like "<T1 F*3c." or "T1 E8004" - command with hex slot and state
+	mmuResponse = regexp.MustCompile(`^(?:MMU2:)?<?\s*([TLUEK])([0-9a-fA-F]+)\s+([PEFAR])([0-9a-fA-F]*)`)
+)
+
+// newMMUStats returns empty MMU counters
+func newMMUStats() *mmuStats {
+	return &mmuStats{
+		loads:   map[string]map[string]float64{},
+		unloads: map[string]map[string]float64{},
+	}
+}
+
+// getMMUSlot converts slot index of MMU protocol (0 based hex) to slot label numbered from 1 as in PrusaLink
+func getMMUSlot(index string) string {
+	number, err := strconv.ParseInt(index, 16, 64)
+	if err != nil {
+		return index
+	}
+	return strconv.FormatInt(number+1, 10)
+}
+
+// recordMMUComm updates MMU counters of the printer with one mmu_comm message
+func recordMMUComm(mac string, message string) {
+	stats := mmuStatsByMac[mac]
+	if stats == nil {
+		stats = newMMUStats()
+		mmuStatsByMac[mac] = stats
+	}
+
+	lower := strings.ToLower(message)
+	if strings.Contains(lower, "retry") || strings.Contains(lower, "retries") {
+		stats.retries++
+		return
+	}
+	if strings.Contains(lower, "timeout") || strings.Contains(lower, "error") || strings.Contains(lower, "crc") {
+		stats.commErrors++
+		return
+	}
+
+	match := mmuResponse.FindStringSubmatch(message)
+	if match == nil {
+		return
+	}
+
+	command, slot, state := match[1], getMMUSlot(match[2]), match[3]
+	key := command + slot + state
+	if key == stats.last {
+		return
+	}
+	stats.last = key
+
+	var result string
+	switch state {
+	case "F":
+		result = "success"
+	case "E":
+		result = "failure"
+	default:
+		return // command is still processing
+	}
+
+	switch command {
+	case "T", "L":
+		if stats.loads[slot] == nil {
+			stats.loads[slot] = map[string]float64{}
+		}
+		stats.loads[slot][result]++
+		if command == "T" && result == "success" {
+			stats.currentSlot = slot
+		}
+	case "U":
+		if stats.unloads[slot] == nil {
+			stats.unloads[slot] = map[string]float64{}
+		}
+		stats.unloads[slot][result]++
+		if result == "success" {
+			stats.currentSlot = ""
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package syslog
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// nominalVoltage is supply voltage of Prusa printers used when the printer does not send any voltage
+const nominalVoltage = 24.0
+
+// getValue returns parsed value of the metric, false when the printer did not send it
+func getValue(metrics map[string]map[string]string, name string) (float64, bool) {
+	if metrics[name] == nil {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(metrics[name]["value"], 64)
+	return value, err == nil
+}
+
+// getRailPower returns power of rails of the printer in W computed from current of the rail in amperes (see milliamperes)
+// and its voltage. Current of dwarf heaters is sent in milliamperes.
+// Rails without own voltage use the supply voltage - 24V rail, bed or nozzle voltage or nominal 24 V.
+func getRailPower(metrics map[string]map[string]string) map[string]float64 {
+	supply := nominalVoltage
+	for _, name := range []string{"24VVoltage", "volt_bed", "volt_nozz"} {
+		if voltage, ok := getValue(metrics, name); ok {
+			supply = voltage
+			break
+		}
+	}
+
+	voltage := func(name string) float64 {
+		if value, ok := getValue(metrics, name); ok {
+			return value
+		}
+		return supply
+	}
+
+	rails := map[string]float64{}
+	if current, ok := getValue(metrics, "curr_inp"); ok {
+		rails["input"] = current * supply
+	}
+	if current, ok := getValue(metrics, "curr_nozz"); ok {
+		rails["nozzle"] = current * voltage("volt_nozz")
+	}
+
+	for name := range metrics {
+		length, base, _ := getNumberOf(name)
+		if length == -1 {
+			continue
+		}
+		if current, ok := getValue(metrics, name); ok {
+			switch base {
+			case "bed_curr":
+				rails["bed_"+strconv.Itoa(length)] = current * voltage("volt_bed")
+			case "bedlet_curr":
+				rails["bedlet_"+strconv.Itoa(length)] = current * voltage("volt_bed")
+			case "dwarf_heat_curr":
+				rails["dwarf_"+strconv.Itoa(length)] = current / milliamperes * supply
+			}
+		}
+	}
+
+	return rails
+}
+
+// getPrinterPower returns power of the whole printer in W. Input rail feeds the board, on some printers (e.g. XL) heaters
+// are powered around it, so the larger of input power and sum of the other rails is used.
+func getPrinterPower(rails map[string]float64) (float64, bool) {
+	names := []string{}
+	for name := range rails {
+		if name != "input" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	power := 0.0
+	for _, name := range names { // sorted so the sum does not change with order of the map
+		power += rails[name]
+	}
+	return math.Max(power, rails["input"]), len(rails) > 0
+}
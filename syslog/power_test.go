@@ -0,0 +1,31 @@
+package syslog
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPrinterPower(t *testing.T) {
+	metric := func(value string) map[string]string { return map[string]string{"value": value} }
+
+	tests := []struct {
+		name    string
+		metrics map[string]map[string]string
+		want    float64
+		ok      bool
+	}{
+		{"no currents", map[string]map[string]string{"volt_bed": metric("24")}, 0, false},
+		{"input covers nozzle", map[string]map[string]string{"24VVoltage": metric("24"), "curr_inp": metric("2"), "curr_nozz": metric("1")}, 48, true},
+		{"bed around input", map[string]map[string]string{"volt_bed": metric("24"), "curr_inp": metric("1"), "bed_curr_0": metric("2"), "dwarf_heat_curr_1": metric("500")}, 60, true},
+		{"nominal voltage", map[string]map[string]string{"curr_nozz": metric("1.5")}, 36, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := getPrinterPower(getRailPower(test.metrics))
+			if ok != test.ok || math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("got %v, %v, want %v, %v", got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
@@ -2,15 +2,81 @@ package syslog
 
 import (
 	"log"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/pstrobl96/prusa_exporter/prusalink"
+)
+
+var (
+	// customLabels are sorted names of custom labels of configured printers, they follow ip in labels of metrics
+	customLabels []string
+
+	// customLabelValues are values of customLabels of configured and discovered printers by ip, printers that are not
+	// scraped have empty values
+	customLabelValues = map[string][]string{}
+
+	labelsConfig config.Config // configuration with groups and printers of custom labels
+	labelsMutex  sync.RWMutex
 )
 
 func getLabels(mac string, ip string, labels []string, labelValues ...string) []string {
 	labelValues = append(labelValues, labels...)
-	return append([]string{mac, ip}, labelValues...)
+	labelsMutex.RLock()
+	values, ok := customLabelValues[ip]
+	labelsMutex.RUnlock()
+	if !ok {
+		values = make([]string, len(customLabels))
+	}
+	return append(append([]string{mac, ip}, values...), labelValues...)
+}
+
+// getCustomLabels returns custom labels of the printer with the ip address without empty ones
+func getCustomLabels(ip string) map[string]string {
+	labelsMutex.RLock()
+	defer labelsMutex.RUnlock()
+
+	labels := map[string]string{}
+	for i, value := range customLabelValues[ip] {
+		if value != "" {
+			labels[customLabels[i]] = value
+		}
+	}
+	return labels
+}
+
+// setCustomLabels stores names of custom labels and values of configured printers
+func setCustomLabels(config config.Config) {
+	customLabels = config.GetLabelNames()
+	labelsConfig = config
+	updateCustomLabels()
+}
+
+// updateCustomLabels looks up values of custom labels of configured printers and of printers added by discovery, printers
+// are matched to syslog senders by host of their address
+func updateCustomLabels() {
+	values := map[string][]string{}
+	for _, printer := range append(slices.Clone(labelsConfig.Printers), prusalink.GetPrinters()...) {
+		host := getHost(printer.Address)
+		if _, ok := values[host]; ok {
+			continue
+		}
+
+		labels := labelsConfig.GetPrinterLabels(printer)
+		printerValues := []string{}
+		for _, name := range customLabels {
+			printerValues = append(printerValues, labels[name])
+		}
+		values[host] = printerValues
+	}
+
+	labelsMutex.Lock()
+	customLabelValues = values
+	labelsMutex.Unlock()
 }
 
 func getNumberOf(s string) (int, string, error) {
@@ -29,6 +95,9 @@ func getNumberOf(s string) (int, string, error) {
 
 // Collector is a struct that defines all the syslog metrics
 type Collector struct {
+	names                        *prusalink.MetricNames
+	printerNetworkReceived       *prometheus.Desc // v2 name of printerNetworkIn with interface label
+	printerNetworkSent           *prometheus.Desc // v2 name of printerNetworkOut with interface label
 	printerActiveExtruder        *prometheus.Desc
 	printerAppStart              *prometheus.Desc
 	printerAxisZAdjustment       *prometheus.Desc
@@ -38,6 +107,8 @@ type Collector struct {
 	printerBedletRegulationTc    *prometheus.Desc // bedlet_regulation_tc
 	printerBedletState           *prometheus.Desc // bedlet_state
 	printerBedState              *prometheus.Desc
+	printerBedMeshZ              *prometheus.Desc // probe_z of the latest mesh
+	printerBedMeshPoints         *prometheus.Desc
 	printerBuddyBom              *prometheus.Desc
 	printerBuddyRevision         *prometheus.Desc
 	printerBuddyFW               *prometheus.Desc
@@ -50,6 +121,7 @@ type Collector struct {
 	printerDwarfFastRefreshDelay *prometheus.Desc
 	printerDwarfParkedRaw        *prometheus.Desc
 	printerDwarfPickedRaw        *prometheus.Desc
+	printerDwarfActive           *prometheus.Desc
 	printerEeepromWrite          *prometheus.Desc
 	printerExciteFreq            *prometheus.Desc
 	printerFanActive             *prometheus.Desc
@@ -86,6 +158,11 @@ type Collector struct {
 	printerMaintaskLoop          *prometheus.Desc
 	printerMediaPrefetched       *prometheus.Desc
 	printerMMUComm               *prometheus.Desc
+	printerMMUCurrentSlot        *prometheus.Desc
+	printerMMULoads              *prometheus.Desc
+	printerMMUUnloads            *prometheus.Desc
+	printerMMUCommErrors         *prometheus.Desc
+	printerMMUCommRetries        *prometheus.Desc
 	printerModbusReqfail         *prometheus.Desc
 	printerNetworkIn             *prometheus.Desc
 	printerNetworkOut            *prometheus.Desc
@@ -103,9 +180,21 @@ type Collector struct {
 	printerProbeZ                *prometheus.Desc // probe_z
 	printerProbeZDiff            *prometheus.Desc
 	printerPwm                   *prometheus.Desc
+	printerRailPower             *prometheus.Desc
+	printerQualityMeshDeviation  *prometheus.Desc
+	printerQualityProbeSpread    *prometheus.Desc
+	printerQualityProbeAnalysis  *prometheus.Desc
+	printerQualityHoming         *prometheus.Desc
+	printerQualityToolDrift      *prometheus.Desc
+	printerQualityLoadcellNoise  *prometheus.Desc
 	printerSideFSensor           *prometheus.Desc // side_fsensor
 	printerSideFSensorRaw        *prometheus.Desc
 	printerSyslogInfo            *prometheus.Desc // revision, bom
+	printerStepperStallGuard     *prometheus.Desc // histogram of tmc_sg
+	printerStepperBaseline       *prometheus.Desc
+	printerStepperDrift          *prometheus.Desc
+	printerStepperCrashes        *prometheus.Desc
+	printerStepperHealth         *prometheus.Desc
 	printerTmcRead               *prometheus.Desc
 	printerTmcSg                 *prometheus.Desc
 	printerTmcWrite              *prometheus.Desc
@@ -130,15 +219,16 @@ type Collector struct {
 // NewCollector is a function that returns new Collector
 // NewCollector creates a new instance of the Collector struct with the provided configuration.
 // It initializes all the Prometheus metrics used for monitoring different aspects of the printer.
-// The defaultLabels parameter is a list of labels that will be included in all the metrics.
+// The defaultLabels parameter is a list of labels that will be included in all the metrics - mac, ip and custom labels of printers.
 // Returns a pointer to the created Collector.
-func NewCollector(syslogTTL int) *Collector {
-	defaultLabels := []string{"mac", "ip"}
+func NewCollector(syslogTTL int, metricNames string, config config.Config) *Collector {
+	setCustomLabels(config)
+	defaultLabels := slices.Clip(append([]string{"mac", "ip"}, customLabels...))
 	if syslogTTL < 1 {
 		log.Panic("syslog TTL must be greater than 0")
 	}
 	ttl = syslogTTL
-	return &Collector{
+	collector := &Collector{
 		printerActiveExtruder:        prometheus.NewDesc("prusa_active_extruder", "Active extruder - used for XL", defaultLabels, nil),
 		printerAppStart:              prometheus.NewDesc("prusa_app_start", "Application start", defaultLabels, nil),
 		printerAxisZAdjustment:       prometheus.NewDesc("prusa_axis_z_adjustment", "Axis Z adjustment", defaultLabels, nil),
@@ -147,6 +237,8 @@ func NewCollector(syslogTTL int) *Collector {
 		printerBedletRegulationP:     prometheus.NewDesc("prusa_bedlet_regulation_p", "Bedlet regulation p value", append(defaultLabels, "bedlet"), nil),
 		printerBedletRegulationTc:    prometheus.NewDesc("prusa_bedlet_regulation_tc", "Bedlet regulation tc value", append(defaultLabels, "bedlet"), nil),
 		printerBedletState:           prometheus.NewDesc("prusa_bedlet_state", "Bedlet state", append(defaultLabels, "bedlet"), nil),
+		printerBedMeshZ:              prometheus.NewDesc("prusa_bed_mesh_z_meters", "Probed Z of points of the latest bed mesh, x and y are coordinates in mm", append(defaultLabels, "x", "y"), nil),
+		printerBedMeshPoints:         prometheus.NewDesc("prusa_bed_mesh_points", "Number of probed points of the latest bed mesh", defaultLabels, nil),
 		printerBedState:              prometheus.NewDesc("prusa_bed_state", "Bed state", defaultLabels, nil),
 		printerBuddyBom:              prometheus.NewDesc("prusa_buddy_bom", "Buddy bom", defaultLabels, nil),
 		printerBuddyRevision:         prometheus.NewDesc("prusa_buddy_revision", "Buddy revision", defaultLabels, nil),
@@ -155,11 +247,12 @@ func NewCollector(syslogTTL int) *Collector {
 		printerCrashSpeed:            prometheus.NewDesc("prusa_crash_speed", "Crash Speed", append(defaultLabels, "axis", "sens", "period"), nil),
 		printerCrashLength:           prometheus.NewDesc("prusa_crash_length", "Crash length", append(defaultLabels, "x", "y"), nil),
 		printerCrashStat:             prometheus.NewDesc("prusa_crash_stat", "Crash statistics", append(defaultLabels, "axis"), nil),
-		printerCurrent:               prometheus.NewDesc("prusa_current", "Current of different devices in / on the printer in miliampers", append(defaultLabels, "rail", "device"), nil),
+		printerCurrent:               prometheus.NewDesc("prusa_current", "Current of different devices in / on the printer in miliampers", append(defaultLabels, "rail", "device", "tool"), nil),
 		printerCurrentRaw:            prometheus.NewDesc("prusa_current_raw", "Current of different devices in / on the printer in raw sensor value", append(defaultLabels, "rail", "device"), nil),
 		printerDwarfFastRefreshDelay: prometheus.NewDesc("prusa_dwarf_fast_refresh_delay", "Dwarf fast refresh delay", defaultLabels, nil),
 		printerDwarfParkedRaw:        prometheus.NewDesc("prusa_dwarf_parked_raw", "Dwarf parked raw sensor value", append(defaultLabels, "tool"), nil),
 		printerDwarfPickedRaw:        prometheus.NewDesc("prusa_dwarf_picked_raw", "Dwarf picked raw sensor value", append(defaultLabels, "tool"), nil),
+		printerDwarfActive:           prometheus.NewDesc("prusa_dwarf_active", "Returns 1 for the dwarf of active extruder - used for XL", append(defaultLabels, "tool"), nil),
 		printerEeepromWrite:          prometheus.NewDesc("prusa_eeeprom_write", "Eeeprom write", defaultLabels, nil),
 		printerExciteFreq:            prometheus.NewDesc("prusa_excite_freq", "Excite frequency", defaultLabels, nil),
 		printerFanActive:             prometheus.NewDesc("prusa_fan_active", "Fan active", append(defaultLabels, "fan"), nil),
@@ -196,6 +289,11 @@ func NewCollector(syslogTTL int) *Collector {
 		printerMaintaskLoop:          prometheus.NewDesc("prusa_maintask_loop", "Maintask loop", defaultLabels, nil),
 		printerMediaPrefetched:       prometheus.NewDesc("prusa_media_prefetched_bytes", "Media prefetched in bytes", defaultLabels, nil),
 		printerMMUComm:               prometheus.NewDesc("prusa_mmu_comm", "MMU communication", append(defaultLabels, "msg"), nil),
+		printerMMUCurrentSlot:        prometheus.NewDesc("prusa_mmu_current_slot", "MMU slot with filament loaded into nozzle numbered from 1, 0 when unloaded", defaultLabels, nil),
+		printerMMULoads:              prometheus.NewDesc("prusa_mmu_load_total", "MMU filament loads by slot and result", append(defaultLabels, "slot", "result"), nil),
+		printerMMUUnloads:            prometheus.NewDesc("prusa_mmu_unload_total", "MMU filament unloads by slot and result", append(defaultLabels, "slot", "result"), nil),
+		printerMMUCommErrors:         prometheus.NewDesc("prusa_mmu_comm_errors_total", "MMU communication errors", defaultLabels, nil),
+		printerMMUCommRetries:        prometheus.NewDesc("prusa_mmu_comm_retries_total", "MMU communication retries", defaultLabels, nil),
 		printerModbusReqfail:         prometheus.NewDesc("prusa_modbus_reqfail", "Modbus request fail", defaultLabels, nil),
 		printerNetworkIn:             prometheus.NewDesc("prusa_network_in_total", "Network in", append(defaultLabels, "device"), nil),
 		printerNetworkOut:            prometheus.NewDesc("prusa_network_out_total", "Network out", append(defaultLabels, "device"), nil),
@@ -213,14 +311,26 @@ func NewCollector(syslogTTL int) *Collector {
 		printerProbeZ:                prometheus.NewDesc("prusa_probe_z", "Probe Z", append(defaultLabels, "x", "y"), nil),
 		printerProbeZDiff:            prometheus.NewDesc("prusa_probe_z_diff", "Probe Z difference", defaultLabels, nil),
 		printerPwm:                   prometheus.NewDesc("prusa_pwm", "PWM value of nozzle and bed mostly", append(defaultLabels, "device"), nil),
+		printerRailPower:             prometheus.NewDesc("prusa_rail_power_watts", "Power of the rail computed from its current and voltage", append(defaultLabels, "rail"), nil),
+		printerQualityMeshDeviation:  prometheus.NewDesc("prusa_quality_bed_mesh_deviation_meters", "Difference of the highest and the lowest point of the latest bed mesh", defaultLabels, nil),
+		printerQualityProbeSpread:    prometheus.NewDesc("prusa_quality_probe_spread_meters", "Standard deviation of probe Z difference of recent probes", defaultLabels, nil),
+		printerQualityProbeAnalysis:  prometheus.NewDesc("prusa_quality_probe_analysis_ok_ratio", "Ratio of successful probe analyses of recent probes", defaultLabels, nil),
+		printerQualityHoming:         prometheus.NewDesc("prusa_quality_homing_repeatability_meters", "Standard deviation of home diff of recent homings", append(defaultLabels, "axis"), nil),
+		printerQualityToolDrift:      prometheus.NewDesc("prusa_quality_tool_offset_drift_meters", "Change of tool offset since the oldest calibration in history", append(defaultLabels, "tool", "axis"), nil),
+		printerQualityLoadcellNoise:  prometheus.NewDesc("prusa_quality_loadcell_noise", "Standard deviation of recent loadcell values", defaultLabels, nil),
 		printerSideFSensor:           prometheus.NewDesc("prusa_side_fsensor", "Side Filament Sensor", defaultLabels, nil),
 		printerSideFSensorRaw:        prometheus.NewDesc("prusa_side_fsensor_raw", "Side Filament Sensor - raw sensor value", append(defaultLabels, "sensor"), nil),
+		printerStepperStallGuard:     prometheus.NewDesc("prusa_stepper_stallguard", "Distribution of StallGuard values of Trinamic driver", append(defaultLabels, "axis"), nil),
+		printerStepperBaseline:       prometheus.NewDesc("prusa_stepper_stallguard_baseline", "Mean StallGuard value learned as normal of the axis", append(defaultLabels, "axis"), nil),
+		printerStepperDrift:          prometheus.NewDesc("prusa_stepper_stallguard_drift_ratio", "Relative change of mean of recent StallGuard values from the learned normal", append(defaultLabels, "axis"), nil),
+		printerStepperCrashes:        prometheus.NewDesc("prusa_stepper_crashes_total", "Crashes detected by the axis", append(defaultLabels, "axis"), nil),
+		printerStepperHealth:         prometheus.NewDesc("prusa_stepper_health_score", "Health score of the axis from 1 for healthy axis to 0 for axis that needs maintenance", append(defaultLabels, "axis"), nil),
 		printerSyslogInfo:            prometheus.NewDesc("prusa_syslog_info", "Buddy syslog info", append(defaultLabels, "revision", "bom"), nil),
 		printerTmcRead:               prometheus.NewDesc("prusa_tmc_read", "Trinamic read", append(defaultLabels, "axis", "reg_addr", "reg_addr_name"), nil), //     metric_record_custom(&metric_read, ",ax=%c reg=%ui,regn=\"%s\",value=%ui",
 		printerTmcSg:                 prometheus.NewDesc("prusa_tmc_sg", "Trinamic SG", append(defaultLabels, "axis"), nil),
 		printerTmcWrite:              prometheus.NewDesc("prusa_tmc_write", "Trinamic write", append(defaultLabels, "axis", "reg_addr", "reg_addr_name"), nil),
 		printerTKAcceleration:        prometheus.NewDesc("prusa_tk_acceleration", "TK acceleration", defaultLabels, nil),
-		printerTemp:                  prometheus.NewDesc("prusa_temp", "Temperature of different devices in / on the printer", append(defaultLabels, "device"), nil),
+		printerTemp:                  prometheus.NewDesc("prusa_temp", "Temperature of different devices in / on the printer", append(defaultLabels, "device", "tool"), nil),
 		printerTempTarget:            prometheus.NewDesc("prusa_temp_target", "Target temperature of different devices in / on the printer", append(defaultLabels, "device"), nil),
 		printerUsbhErrCount:          prometheus.NewDesc("prusa_usbh_err_count", "USBH error counter", defaultLabels, nil),
 		printerVoltage:               prometheus.NewDesc("prusa_voltage", "Voltage of different devices in / on the printer", append(defaultLabels, "rail", "device"), nil),
@@ -236,10 +346,33 @@ func NewCollector(syslogTTL int) *Collector {
 		printerSyslogUp:              prometheus.NewDesc("prusa_up_syslog", "Printer up - from syslog metric - ttl is by default 60 seconds but can be different and it depends on choosen interval. That means if printer wont sent any data for 60 seconds is considered down.", defaultLabels, nil),
 		printerPrintFilename:         prometheus.NewDesc("prusa_print_filename", "Printed file name", append(defaultLabels, "filename"), nil),
 	}
+
+	gauge := prometheus.GaugeValue
+	collector.printerNetworkReceived = prometheus.NewDesc("prusa_network_received_bytes_total", "Bytes received by the network interface", append(defaultLabels, "interface"), nil)
+	collector.printerNetworkSent = prometheus.NewDesc("prusa_network_sent_bytes_total", "Bytes sent by the network interface", append(defaultLabels, "interface"), nil)
+	collector.names = prusalink.NewMetricNames(metricNames, map[*prometheus.Desc]prusalink.Rename{
+		collector.printerTemp:               prusalink.NewRename(prometheus.NewDesc("prusa_temperature_celsius", "Temperature of different devices in / on the printer in Celsius", append(defaultLabels, "device", "tool"), nil), gauge, 1),
+		collector.printerTempTarget:         prusalink.NewRename(prometheus.NewDesc("prusa_target_temperature_celsius", "Target temperature of different devices in / on the printer in Celsius", append(defaultLabels, "device"), nil), gauge, 1),
+		collector.printerCurrent:            prusalink.NewRename(prometheus.NewDesc("prusa_current_amperes", "Current of different devices in / on the printer in amperes", append(defaultLabels, "rail", "device", "tool"), nil), gauge, 0.001),
+		collector.printerVoltage:            prusalink.NewRename(prometheus.NewDesc("prusa_voltage_volts", "Voltage of different devices in / on the printer in volts", append(defaultLabels, "rail", "device"), nil), gauge, 1),
+		collector.printerHeapFree:           prusalink.NewRename(prometheus.NewDesc("prusa_heap_free_bytes", "Free heap in bytes", defaultLabels, nil), gauge, 1),
+		collector.printerHeapTotal:          prusalink.NewRename(prometheus.NewDesc("prusa_heap_total_bytes", "Total heap in bytes", defaultLabels, nil), gauge, 1),
+		collector.printerUsbhErrCount:       prusalink.NewRename(prometheus.NewDesc("prusa_usbh_errors_total", "USB host errors", defaultLabels, nil), prometheus.CounterValue, 1),
+		collector.prusaBuddyTimeUs:          prusalink.NewRename(prometheus.NewDesc("prusa_buddy_time_seconds", "Buddy time in seconds", defaultLabels, nil), gauge, 0.000001),
+		collector.prusaPuppyTimeUs:          prusalink.NewRename(prometheus.NewDesc("prusa_puppy_time_seconds", "Puppy time in seconds", defaultLabels, nil), gauge, 0.000001),
+		collector.prusaSyncRoundtripUs:      prusalink.NewRename(prometheus.NewDesc("prusa_sync_roundtrip_seconds", "Sync roundtrip in seconds", defaultLabels, nil), gauge, 0.000001),
+		collector.prusaPuppyOffsetUs:        prusalink.NewRename(prometheus.NewDesc("prusa_puppy_offset_seconds", "Puppy offset in seconds", defaultLabels, nil), gauge, 0.000001),
+		collector.prusaPuppyAverageOffsetUs: prusalink.NewRename(prometheus.NewDesc("prusa_puppy_average_offset_seconds", "Puppy average offset in seconds", defaultLabels, nil), gauge, 0.000001),
+	})
+
+	return collector
 }
 
 // Describe is a function that describes all the metrics
 func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
+	collector.names.Describe(ch)
+	ch <- collector.printerNetworkReceived
+	ch <- collector.printerNetworkSent
 	ch <- collector.printerActiveExtruder
 	ch <- collector.printerAppStart
 	ch <- collector.printerAxisZAdjustment
@@ -249,6 +382,8 @@ func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.printerBedletRegulationTc
 	ch <- collector.printerBedletState
 	ch <- collector.printerBedState
+	ch <- collector.printerBedMeshZ
+	ch <- collector.printerBedMeshPoints
 	ch <- collector.printerBuddyBom
 	ch <- collector.printerBuddyRevision
 	ch <- collector.printerBuddyFW
@@ -261,6 +396,7 @@ func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.printerDwarfFastRefreshDelay
 	ch <- collector.printerDwarfParkedRaw
 	ch <- collector.printerDwarfPickedRaw
+	ch <- collector.printerDwarfActive
 	ch <- collector.printerEeepromWrite
 	ch <- collector.printerExciteFreq
 	ch <- collector.printerFanActive
@@ -275,6 +411,7 @@ func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.printerG425Rxy
 	ch <- collector.printerG425Rz
 	ch <- collector.printerG425Xy
+	ch <- collector.printerG425XyDev
 	ch <- collector.printerG425Z
 	ch <- collector.printerGcode
 	ch <- collector.printerGuiLoopDuration
@@ -292,9 +429,15 @@ func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.printerLoadcellThresholdCont
 	ch <- collector.printerLoadcellValue
 	ch <- collector.printerLoadcellValueRaw
+	ch <- collector.printerLoadcellXY
 	ch <- collector.printerMaintaskLoop
 	ch <- collector.printerMediaPrefetched
 	ch <- collector.printerMMUComm
+	ch <- collector.printerMMUCurrentSlot
+	ch <- collector.printerMMULoads
+	ch <- collector.printerMMUUnloads
+	ch <- collector.printerMMUCommErrors
+	ch <- collector.printerMMUCommRetries
 	ch <- collector.printerModbusReqfail
 	ch <- collector.printerNetworkIn
 	ch <- collector.printerNetworkOut
@@ -302,6 +445,7 @@ func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.printerPointsDropped
 	ch <- collector.printerPos
 	ch <- collector.printerPowerPanicCount
+	ch <- collector.printerPrinting
 	ch <- collector.printerProbeAnalysis
 	ch <- collector.printerProbeWindowStart
 	ch <- collector.printerProbeWindowFallEnd
@@ -311,8 +455,21 @@ func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.printerProbeZ
 	ch <- collector.printerProbeZDiff
 	ch <- collector.printerPwm
+	ch <- collector.printerRailPower
+	ch <- collector.printerQualityMeshDeviation
+	ch <- collector.printerQualityProbeSpread
+	ch <- collector.printerQualityProbeAnalysis
+	ch <- collector.printerQualityHoming
+	ch <- collector.printerQualityToolDrift
+	ch <- collector.printerQualityLoadcellNoise
 	ch <- collector.printerSideFSensor
 	ch <- collector.printerSideFSensorRaw
+	ch <- collector.printerSyslogInfo
+	ch <- collector.printerStepperStallGuard
+	ch <- collector.printerStepperBaseline
+	ch <- collector.printerStepperDrift
+	ch <- collector.printerStepperCrashes
+	ch <- collector.printerStepperHealth
 	ch <- collector.printerTmcRead
 	ch <- collector.printerTmcSg
 	ch <- collector.printerTmcWrite
@@ -0,0 +1,209 @@
+package syslog
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/expfmt"
+	"github.com/pstrobl96/prusa_exporter/config"
+	"github.com/pstrobl96/prusa_exporter/prusalink"
+	"gopkg.in/mcuadros/go-syslog.v2/format"
+)
+
+var (
+	update = flag.Bool("update", false, "update golden files in testdata/golden")
+
+	// goldenModels are printers with captured syslog packets in testdata/<model>.jsonl
+	goldenModels = []string{"MINI", "MK4", "MK4_MMU3", "XL"}
+
+	variableLabelsRegexp = regexp.MustCompile(`variableLabels: \{([^}]*)\}`)
+)
+
+// loadCapture resets stored metrics and feeds packets from the capture file through the same parsing as HandleMetrics does
+func loadCapture(t *testing.T, models ...string) {
+	t.Helper()
+
+	mutex.Lock()
+	syslogMetrics = map[string]map[string]map[string]string{}
+	mmuStatsByMac = map[string]*mmuStats{}
+	qualityByMac = map[string]*qualityHistory{}
+	meshesByMac = map[string]*meshRecorder{}
+	stepperByMac = map[string]*stepperHealth{}
+	mutex.Unlock()
+
+	for _, model := range models {
+		packets, err := ReadCapture(filepath.Join("testdata", model+".jsonl"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, packet := range packets {
+			parser := (&format.RFC5424{}).GetParser([]byte(packet.Data))
+			if err := parser.Parse(); err != nil {
+				t.Fatalf("error parsing packet %q: %s", packet.Data, err)
+			}
+			logParts := parser.Dump()
+			logParts["client"] = packet.Source
+			handleMetricsPacket(logParts)
+		}
+	}
+}
+
+// gatherText returns metrics of the collector in text exposition format
+func gatherText(t *testing.T, collector prometheus.Collector) string {
+	t.Helper()
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(collector)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToText(&buffer, family); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return buffer.String()
+}
+
+// compareGolden compares got with the golden file, golden file is rewritten when -update flag is set
+func compareGolden(t *testing.T, path string, got string) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(want) != got {
+		t.Errorf("output differs from %s, run go test ./... -update to accept the change\n--- want\n%s\n--- got\n%s", path, want, got)
+	}
+}
+
+func TestCollectGolden(t *testing.T) {
+	for _, model := range goldenModels {
+		t.Run(model, func(t *testing.T) {
+			loadCapture(t, model)
+			compareGolden(t, filepath.Join("testdata", "golden", model+".prom"), gatherText(t, NewCollector(60, "", config.Config{})))
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	loadCapture(t, goldenModels...)
+
+	problems, err := testutil.CollectAndLint(NewCollector(60, "", config.Config{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	for _, problem := range problems {
+		fmt.Fprintf(&buffer, "%s: %s\n", problem.Metric, problem.Text)
+	}
+
+	compareGolden(t, filepath.Join("testdata", "golden", "lint.txt"), buffer.String())
+}
+
+func TestLabelsBuiltin(t *testing.T) {
+	descriptors := make(chan *prometheus.Desc)
+
+	go func() {
+		NewCollector(60, "", config.Config{}).Describe(descriptors)
+		close(descriptors)
+	}()
+
+	for desc := range descriptors {
+		match := variableLabelsRegexp.FindStringSubmatch(desc.String())
+		if match == nil || match[1] == "" {
+			continue
+		}
+		for _, label := range strings.Split(match[1], ",") {
+			if !config.IsBuiltinLabel(label) {
+				t.Errorf("label %s of %s is not a builtin label, custom labels could collide with it", label, desc)
+			}
+		}
+	}
+}
+
+func TestCustomLabels(t *testing.T) {
+	loadCapture(t, "MINI", "MK4")
+
+	var cfg config.Config
+	cfg.Printers = []config.Printers{{Address: "192.168.20.12:80", Group: "farm", Labels: map[string]string{"room": "lab"}}}
+	text := gatherText(t, NewCollector(60, "", cfg))
+
+	for _, want := range []string{
+		`prusa_buddy_bom{group="farm",ip="192.168.20.12",mac="10:9c:70:2c:da:12",room="lab"} 34`,
+		`prusa_buddy_bom{group="",ip="192.168.20.11",mac="10:9c:70:2c:da:11",room=""} 0`,
+		`prusa_buddy_fw{group="farm",ip="192.168.20.12",mac="10:9c:70:2c:da:12",room="lab",version="6.0.0+14794"} 1`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+
+	if labels := getCustomLabels("192.168.20.12"); labels["group"] != "farm" || labels["room"] != "lab" {
+		t.Errorf("custom labels of 192.168.20.12 are %v", labels)
+	}
+	if labels := getCustomLabels("192.168.20.11"); len(labels) != 0 {
+		t.Errorf("custom labels of not configured printer are %v", labels)
+	}
+}
+
+func TestGetHost(t *testing.T) {
+	for address, want := range map[string]string{
+		"192.168.20.12:514":  "192.168.20.12",
+		"192.168.20.12":      "192.168.20.12",
+		"[fd00::12]:514":     "fd00::12",
+		"fd00::12":           "fd00::12",
+		"prusa-mk4.local:80": "prusa-mk4.local",
+	} {
+		if host := getHost(address); host != want {
+			t.Errorf("host of %s is %s, want %s", address, host, want)
+		}
+	}
+}
+
+func TestCustomLabelsDiscovered(t *testing.T) {
+	loadCapture(t, "MINI", "MK4")
+
+	var cfg config.Config
+	cfg.Exporter.Discovery.Enabled = true
+	cfg.Exporter.Discovery.Profile.Labels = map[string]string{"shelf": "top"}
+	prusalink.NewCollector(cfg)
+	t.Cleanup(func() { prusalink.NewCollector(config.Config{}) })
+
+	collector := NewCollector(60, "", cfg)
+	if !strings.Contains(gatherText(t, collector), `prusa_buddy_bom{ip="192.168.20.11",mac="10:9c:70:2c:da:11",shelf=""} 0`) {
+		t.Error("printer that is not scraped has custom labels")
+	}
+
+	// printer added by discovery after the collector was created gets labels of the discovery profile
+	prusalink.AddPrinter(config.Printers{Address: "192.168.20.11", Labels: cfg.Exporter.Discovery.Profile.Labels})
+	if !strings.Contains(gatherText(t, collector), `prusa_buddy_bom{ip="192.168.20.11",mac="10:9c:70:2c:da:11",shelf="top"} 0`) {
+		t.Error("printer added by discovery does not have custom labels")
+	}
+}
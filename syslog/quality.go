@@ -0,0 +1,235 @@
+package syslog
+
+import (
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+)
+
+// qualityHistorySize is number of samples kept in every rolling window of quality history
+const qualityHistorySize = 100
+
+// window is rolling history of samples, the oldest sample is dropped when the window is full
+type window []float64
+
+// qualityHistory is rolling history of calibration values of one printer, values are in mm as sent by firmware
+type qualityHistory struct {
+	probeDiff     window              // probe_z_diff
+	probeAnalysis window              // ok of probe_analysis - 1 or 0
+	homeDiff      map[string]window   // axis -> home_diff
+	toolOffsets   map[string][]window // tool -> x, y and z of g425_off
+	loadcell      window              // loadcell_value
+}
+
+// QualityReport is print quality analytics of one printer derived from its quality history, lengths are in mm
+type QualityReport struct {
+	MAC                 string                        `json:"mac"`
+	IP                  string                        `json:"ip"`
+	MeshPoints          int                           `json:"mesh_points"`                       // points of the latest bed mesh
+	BedMeshDeviation    *float64                      `json:"bed_mesh_deviation_mm,omitempty"`   // difference of the highest and the lowest point of the latest bed mesh
+	ProbeSpread         *float64                      `json:"probe_spread_mm,omitempty"`         // standard deviation of probe_z_diff
+	ProbeAnalysisOk     *float64                      `json:"probe_analysis_ok_ratio,omitempty"` // ratio of successful probe analyses
+	HomingRepeatability map[string]float64            `json:"homing_repeatability_mm,omitempty"` // axis -> standard deviation of home_diff
+	ToolOffsetDrift     map[string]map[string]float64 `json:"tool_offset_drift_mm,omitempty"`    // tool -> axis -> change of g425_off since the oldest calibration
+	ToolCalibrations    map[string]int                `json:"tool_calibrations,omitempty"`       // tool -> number of calibrations in history
+	LoadcellNoise       *float64                      `json:"loadcell_noise,omitempty"`          // standard deviation of loadcell_value
+	Labels              map[string]string             `json:"labels,omitempty"`
+}
+
+var (
+	// qualityByMac contains quality history of printers, it is guarded by mutex together with syslogMetrics
+	qualityByMac = map[string]*qualityHistory{}
+
+	qualityProbeZDiff    = regexp.MustCompile(`^probe_z_diff v=([-\d\.]+)`)
+	qualityProbeAnalysis = regexp.MustCompile(`^probe_analysis ok=([-\d\.]+)`)
+	qualityHomeDiff      = regexp.MustCompile(`^home_diff,ax=([-\d\.]+),ok=[-\d\.]+ v=([-\d\.]+)`)
+	qualityToolOffset    = regexp.MustCompile(`^g425_off,t=([-\d\.]+) x=([-\d\.]+),y=([-\d\.]+),z=([-\d\.]+)`)
+	qualityLoadcell      = regexp.MustCompile(`^loadcell_value v=([-\d\.]+)`)
+
+	// toolOffsetAxis are axis of g425_off in order of toolOffsets windows
+	toolOffsetAxis = []string{"x", "y", "z"}
+)
+
+// add appends the sample to the window and drops the oldest one when the window is full
+func (w window) add(value float64) window {
+	w = append(w, value)
+	if len(w) > qualityHistorySize {
+		w = w[len(w)-qualityHistorySize:]
+	}
+	return w
+}
+
+// stddev returns population standard deviation of the window, false when there are less than two samples
+func (w window) stddev() (float64, bool) {
+	if len(w) < 2 {
+		return 0, false
+	}
+
+	mean := 0.0
+	for _, value := range w {
+		mean += value
+	}
+	mean /= float64(len(w))
+
+	variance := 0.0
+	for _, value := range w {
+		variance += (value - mean) * (value - mean)
+	}
+
+	return math.Sqrt(variance / float64(len(w))), true
+}
+
+// mean returns average of the window, false when the window is empty
+func (w window) mean() (float64, bool) {
+	if len(w) == 0 {
+		return 0, false
+	}
+
+	sum := 0.0
+	for _, value := range w {
+		sum += value
+	}
+	return sum / float64(len(w)), true
+}
+
+// newQualityHistory returns empty quality history
+func newQualityHistory() *qualityHistory {
+	return &qualityHistory{
+		homeDiff:    map[string]window{},
+		toolOffsets: map[string][]window{},
+	}
+}
+
+// recordQuality updates quality history of the printer with one metric message, every message is needed
+// because syslogMetrics keeps only the last value of the metric
+func recordQuality(mac string, message string) {
+	parse := func(values ...string) ([]float64, bool) {
+		parsed := make([]float64, len(values))
+		for i, value := range values {
+			var err error
+			if parsed[i], err = strconv.ParseFloat(value, 64); err != nil {
+				log.Error().Msg("Error parsing quality value " + value + " - " + err.Error())
+				return nil, false
+			}
+		}
+		return parsed, true
+	}
+
+	history := qualityByMac[mac]
+	if history == nil {
+		history = newQualityHistory()
+		qualityByMac[mac] = history
+	}
+
+	if match := qualityProbeZDiff.FindStringSubmatch(message); match != nil {
+		if values, ok := parse(match[1]); ok {
+			history.probeDiff = history.probeDiff.add(values[0])
+		}
+	} else if match := qualityProbeAnalysis.FindStringSubmatch(message); match != nil {
+		if values, ok := parse(match[1]); ok {
+			history.probeAnalysis = history.probeAnalysis.add(math.Min(values[0], 1))
+		}
+	} else if match := qualityHomeDiff.FindStringSubmatch(message); match != nil {
+		if values, ok := parse(match[2]); ok {
+			history.homeDiff[match[1]] = history.homeDiff[match[1]].add(values[0])
+		}
+	} else if match := qualityToolOffset.FindStringSubmatch(message); match != nil {
+		if values, ok := parse(match[2], match[3], match[4]); ok {
+			offsets := history.toolOffsets[match[1]]
+			if offsets == nil {
+				offsets = make([]window, len(toolOffsetAxis))
+			}
+			for i := range toolOffsetAxis {
+				offsets[i] = offsets[i].add(values[i])
+			}
+			history.toolOffsets[match[1]] = offsets
+		}
+	} else if match := qualityLoadcell.FindStringSubmatch(message); match != nil {
+		if values, ok := parse(match[1]); ok {
+			history.loadcell = history.loadcell.add(values[0])
+		}
+	}
+}
+
+// getQualityReport derives print quality analytics from quality history and the latest bed mesh of the printer,
+// it has to be called with mutex locked
+func getQualityReport(mac string, ip string, history *qualityHistory) QualityReport {
+	report := QualityReport{MAC: mac, IP: ip, Labels: getCustomLabels(ip)}
+
+	if recorder := meshesByMac[mac]; recorder != nil {
+		if mesh, ok := recorder.latest(); ok {
+			lowest, highest := math.Inf(1), math.Inf(-1)
+			for _, point := range mesh.Points {
+				lowest = math.Min(lowest, point.Z)
+				highest = math.Max(highest, point.Z)
+			}
+			deviation := highest - lowest
+			report.MeshPoints = len(mesh.Points)
+			report.BedMeshDeviation = &deviation
+		}
+	}
+
+	if spread, ok := history.probeDiff.stddev(); ok {
+		report.ProbeSpread = &spread
+	}
+
+	if ratio, ok := history.probeAnalysis.mean(); ok {
+		report.ProbeAnalysisOk = &ratio
+	}
+
+	for axis, diffs := range history.homeDiff {
+		if repeatability, ok := diffs.stddev(); ok {
+			if report.HomingRepeatability == nil {
+				report.HomingRepeatability = map[string]float64{}
+			}
+			report.HomingRepeatability[axis] = repeatability
+		}
+	}
+
+	for tool, offsets := range history.toolOffsets {
+		if report.ToolOffsetDrift == nil {
+			report.ToolOffsetDrift = map[string]map[string]float64{}
+			report.ToolCalibrations = map[string]int{}
+		}
+		report.ToolCalibrations[tool] = len(offsets[0])
+		report.ToolOffsetDrift[tool] = map[string]float64{}
+		for i, axis := range toolOffsetAxis {
+			report.ToolOffsetDrift[tool][axis] = offsets[i][len(offsets[i])-1] - offsets[i][0]
+		}
+	}
+
+	if noise, ok := history.loadcell.stddev(); ok {
+		report.LoadcellNoise = &noise
+	}
+
+	return report
+}
+
+// GetQualityReports returns print quality analytics of all printers that sent metrics, sorted by MAC address
+func GetQualityReports() []QualityReport {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	reports := []QualityReport{}
+	for mac, history := range qualityByMac {
+		reports = append(reports, getQualityReport(mac, getIP(mac), history))
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].MAC < reports[j].MAC
+	})
+
+	return reports
+}
+
+// QualityHandler returns handler of print quality reports at the prefix - list of all printers at prefix
+// and report of one printer at prefix/<mac or ip>
+func QualityHandler(prefix string) http.Handler {
+	return printerHandler(prefix, GetQualityReports, func(report QualityReport) []string {
+		return []string{report.MAC, report.IP}
+	})
+}
@@ -0,0 +1,66 @@
+package syslog
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQualityHandler(t *testing.T) {
+	loadCapture(t, "MK4", "XL")
+
+	server := httptest.NewServer(QualityHandler("/quality"))
+	defer server.Close()
+
+	var reports []QualityReport
+	response, err := http.Get(server.URL + "/quality/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.NewDecoder(response.Body).Decode(&reports); err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if len(reports) != 2 {
+		t.Fatalf("got %d reports, want 2", len(reports))
+	}
+
+	var report QualityReport
+	response, err = http.Get(server.URL + "/quality/192.168.20.13")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.NewDecoder(response.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if report.MeshPoints != 6 || report.BedMeshDeviation == nil || math.Abs(*report.BedMeshDeviation-0.083) > 1e-9 {
+		t.Errorf("got %d mesh points with deviation %v, want 6 and 0.083", report.MeshPoints, report.BedMeshDeviation)
+	}
+
+	if report.ProbeAnalysisOk == nil || *report.ProbeAnalysisOk != 0.5 {
+		t.Errorf("got probe analysis ok ratio %v, want 0.5", report.ProbeAnalysisOk)
+	}
+
+	if math.Abs(report.HomingRepeatability["0"]-0.01) > 1e-9 {
+		t.Errorf("got homing repeatability of axis 0 %v, want 0.01", report.HomingRepeatability["0"])
+	}
+
+	if report.ToolCalibrations["1"] != 2 || math.Abs(report.ToolOffsetDrift["1"]["z"]+0.015) > 1e-9 {
+		t.Errorf("got %d calibrations of tool 1 with z drift %v, want 2 and -0.015", report.ToolCalibrations["1"], report.ToolOffsetDrift["1"]["z"])
+	}
+
+	response, err = http.Get(server.URL + "/quality/192.168.20.99")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("got status %d for unknown printer, want 404", response.StatusCode)
+	}
+}
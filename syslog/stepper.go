@@ -0,0 +1,324 @@
+package syslog
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// stepperBaselineSamples is the least number of StallGuard samples of the axis learned as its normal
+	stepperBaselineSamples = 100
+
+	// stepperBaselineDuration is printing time of the printer from which the normal of the axis is learned
+	stepperBaselineDuration = 2 * time.Hour
+
+	// stepperSampleGap is the longest time between two samples that is counted as printing time of the normal
+	stepperSampleGap = time.Minute
+
+	// defaultStepperFile is where learned normals are stored when file is not configured
+	defaultStepperFile = "steppers.json"
+
+	// stepperCrashWindow is time for which a crash lowers health score of the axis
+	stepperCrashWindow = time.Hour
+)
+
+var (
+	// stepperByMac contains stepper health of printers, it is guarded by mutex together with syslogMetrics
+	stepperByMac = map[string]*stepperHealth{}
+
+	// stepperSaveInterval is how often changed normals are written to the file, like odometers of maintenance
+	stepperSaveInterval = time.Minute
+
+	stepperFile   = defaultStepperFile
+	steppersDirty bool // normals changed since they were saved, guarded by mutex
+
+	// stallGuardBuckets are upper bounds of StallGuard histogram, TMC drivers report 0 - 1023
+	stallGuardBuckets = []float64{25, 50, 100, 150, 200, 300, 400, 500, 750, 1023}
+
+	// crashAxis are names of axis sent by index in crash messages
+	crashAxis = map[string]string{"0": "x", "1": "y", "2": "z"}
+
+	stepperStallGuard = regexp.MustCompile(`^tmc_sg_(\w+) v=([-\d\.]+)`)
+	stepperCrash      = regexp.MustCompile(`^crash,axis=(\w+) `)
+)
+
+// stepperHealth is StallGuard history and crashes of steppers of one printer by axis
+type stepperHealth struct {
+	printing   bool // last is_printing, normal is learned only from samples taken while printing
+	stallGuard map[string]*stallGuardHistory
+	crashes    map[string]float64     // axis -> crashes since start of the exporter
+	lastCrash  map[string][]time.Time // axis -> times of crashes in stepperCrashWindow
+}
+
+// stallGuardHistory is StallGuard distribution, learned normal and recent values of one axis
+type stallGuardHistory struct {
+	count    uint64
+	sum      float64
+	buckets  map[float64]uint64 // upper bound -> cumulative count
+	baseline *stepperBaseline
+	recent   window
+}
+
+// stepperBaseline is StallGuard normal of one axis learned from samples taken during stepperBaselineDuration of printing,
+// it is stored in the stepper file so the normal survives restarts of the exporter
+type stepperBaseline struct {
+	Mac             string    `json:"mac"`
+	Axis            string    `json:"axis"`
+	Sum             float64   `json:"sum"`
+	Samples         int       `json:"samples"`
+	PrintingSeconds float64   `json:"printing_seconds"`
+	last            time.Time // previous sample taken while printing
+}
+
+// learned returns true when the normal has enough samples and printing time
+func (baseline *stepperBaseline) learned() bool {
+	return baseline.Samples >= stepperBaselineSamples && baseline.PrintingSeconds >= stepperBaselineDuration.Seconds()
+}
+
+// newStallGuardHistory returns empty StallGuard history of the axis
+func newStallGuardHistory(mac string, axis string) *stallGuardHistory {
+	history := &stallGuardHistory{buckets: map[float64]uint64{}, baseline: &stepperBaseline{Mac: mac, Axis: axis}}
+	for _, bound := range stallGuardBuckets {
+		history.buckets[bound] = 0 // every bucket is returned so series do not appear later
+	}
+	return history
+}
+
+// stepperAxis is health of the stepper of one axis, health score is 1 for healthy axis and 0 for axis that needs maintenance
+type stepperAxis struct {
+	axis        string
+	baseline    *float64 // mean StallGuard of the learned normal
+	drift       *float64 // relative change of mean of recent StallGuard samples from the baseline
+	crashes     float64  // crashes since start of the exporter
+	lastCrashes int      // crashes in stepperCrashWindow
+	healthScore *float64
+}
+
+// newStepperHealth returns empty stepper health
+func newStepperHealth() *stepperHealth {
+	return &stepperHealth{
+		stallGuard: map[string]*stallGuardHistory{},
+		crashes:    map[string]float64{},
+		lastCrash:  map[string][]time.Time{},
+	}
+}
+
+// recordStepper updates stepper health of the printer with one metric message
+func recordStepper(mac string, message string, received time.Time) {
+	health := stepperByMac[mac]
+	if health == nil {
+		health = newStepperHealth()
+		stepperByMac[mac] = health
+	}
+
+	if match := meshIsPrinting.FindStringSubmatch(message); match != nil {
+		health.printing = match[1] != "0"
+	} else if match := stepperStallGuard.FindStringSubmatch(message); match != nil {
+		value, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			log.Error().Msg("Error parsing StallGuard value " + match[2] + " - " + err.Error())
+			return
+		}
+
+		history := health.stallGuard[match[1]]
+		if history == nil {
+			history = newStallGuardHistory(mac, match[1])
+			health.stallGuard[match[1]] = history
+		}
+
+		history.count++
+		history.sum += value
+		for _, bound := range stallGuardBuckets {
+			if value <= bound {
+				history.buckets[bound]++
+			}
+		}
+		history.recent = history.recent.add(value)
+
+		baseline := history.baseline
+		if !health.printing || baseline.learned() {
+			baseline.last = time.Time{} // idle time is not counted as printing
+			return
+		}
+		if !baseline.last.IsZero() && received.Sub(baseline.last) <= stepperSampleGap {
+			baseline.PrintingSeconds += received.Sub(baseline.last).Seconds()
+		}
+		baseline.last = received
+		baseline.Sum += value
+		baseline.Samples++
+		steppersDirty = true
+	} else if match := stepperCrash.FindStringSubmatch(message); match != nil {
+		axis := strings.ToLower(match[1])
+		if name, ok := crashAxis[axis]; ok {
+			axis = name
+		}
+		health.crashes[axis]++
+
+		recent := []time.Time{}
+		for _, crash := range health.lastCrash[axis] {
+			if received.Sub(crash) < stepperCrashWindow {
+				recent = append(recent, crash)
+			}
+		}
+		health.lastCrash[axis] = append(recent, received)
+	}
+}
+
+// getStepperAxis returns health of steppers of all axis of the printer at the time, sorted by axis. Drift and health score
+// are known only after the normal of the axis is learned - score is lowered by 2 for every 100 % of drift and by 0.1
+// for every crash in the last hour.
+func getStepperAxis(health *stepperHealth, now time.Time) []stepperAxis {
+	axis := map[string]*stepperAxis{}
+	get := func(name string) *stepperAxis {
+		if axis[name] == nil {
+			axis[name] = &stepperAxis{axis: name}
+		}
+		return axis[name]
+	}
+
+	for name, crashes := range health.crashes {
+		stepper := get(name)
+		stepper.crashes = crashes
+		for _, crash := range health.lastCrash[name] {
+			if now.Sub(crash) < stepperCrashWindow {
+				stepper.lastCrashes++
+			}
+		}
+	}
+
+	for name, history := range health.stallGuard {
+		stepper := get(name)
+		if !history.baseline.learned() {
+			continue // normal is still being learned
+		}
+
+		baseline := history.baseline.Sum / float64(history.baseline.Samples)
+		recent, _ := history.recent.mean()
+		drift := 0.0
+		if baseline != 0 {
+			drift = (recent - baseline) / baseline
+		}
+		score := math.Max(0, math.Min(1, 1-2*math.Abs(drift)-0.1*float64(stepper.lastCrashes)))
+
+		stepper.baseline = &baseline
+		stepper.drift = &drift
+		stepper.healthScore = &score
+	}
+
+	steppers := []stepperAxis{}
+	for _, stepper := range axis {
+		steppers = append(steppers, *stepper)
+	}
+	sort.Slice(steppers, func(i, j int) bool {
+		return steppers[i].axis < steppers[j].axis
+	})
+	return steppers
+}
+
+// LoadSteppers reads learned normals from the file, missing file means that no normal is learned yet
+func LoadSteppers(path string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if path != "" {
+		stepperFile = path
+	}
+	steppersDirty = false
+
+	data, err := os.ReadFile(stepperFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var stored []*stepperBaseline
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+
+	for _, baseline := range stored {
+		health := stepperByMac[baseline.Mac]
+		if health == nil {
+			health = newStepperHealth()
+			stepperByMac[baseline.Mac] = health
+		}
+		history := newStallGuardHistory(baseline.Mac, baseline.Axis)
+		history.baseline = baseline
+		health.stallGuard[baseline.Axis] = history
+	}
+	return nil
+}
+
+// saveSteppers writes normals of all axis to the file, it has to be called with mutex locked.
+// File is replaced at once so it is never left half written.
+func saveSteppers() error {
+	stored := []*stepperBaseline{}
+	for _, health := range stepperByMac {
+		for _, history := range health.stallGuard {
+			stored = append(stored, history.baseline)
+		}
+	}
+	sort.Slice(stored, func(i, j int) bool {
+		if stored[i].Mac != stored[j].Mac {
+			return stored[i].Mac < stored[j].Mac
+		}
+		return stored[i].Axis < stored[j].Axis
+	})
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(stepperFile), filepath.Base(stepperFile)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temp.Name(), stepperFile); err != nil {
+		return err
+	}
+
+	steppersDirty = false
+	return nil
+}
+
+// SaveSteppers writes normals to the file when they changed since they were saved
+func SaveSteppers() error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if !steppersDirty {
+		return nil
+	}
+	return saveSteppers()
+}
+
+// RunStepperSaves saves changed normals in stepperSaveInterval, it never returns
+func RunStepperSaves() {
+	for {
+		time.Sleep(stepperSaveInterval)
+		if err := SaveSteppers(); err != nil {
+			log.Error().Msg("Error while saving stepper normals to " + stepperFile + " - " + err.Error())
+		}
+	}
+}
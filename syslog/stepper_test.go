@@ -0,0 +1,86 @@
+package syslog
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// learnStepper sends StallGuard samples of axis x every 30 seconds of printing until the normal is learned,
+// it returns time of the last sample
+func learnStepper(mac string, value string, start time.Time) time.Time {
+	recordStepper(mac, "is_printing v=1i", start)
+	now := start
+	for i := 0; i <= int(stepperBaselineDuration/(30*time.Second)); i++ {
+		now = start.Add(time.Duration(i) * 30 * time.Second)
+		recordStepper(mac, "tmc_sg_x v="+value, now)
+	}
+	return now
+}
+
+func TestStepperHealth(t *testing.T) {
+	loadCapture(t)
+	now := time.Now()
+
+	// samples of idle printer are not learned as normal
+	for i := 0; i < 2*stepperBaselineSamples; i++ {
+		recordStepper("mac", "tmc_sg_x v=50i", now.Add(time.Duration(i)*time.Second))
+	}
+	if steppers := getStepperAxis(stepperByMac["mac"], now); len(steppers) != 1 || steppers[0].healthScore != nil {
+		t.Fatalf("got %+v, want axis x without normal of idle printer", steppers)
+	}
+
+	now = learnStepper("mac", "200i", now.Add(time.Hour))
+	steppers := getStepperAxis(stepperByMac["mac"], now)
+	if len(steppers) != 1 || steppers[0].healthScore == nil || *steppers[0].healthScore != 1 {
+		t.Fatalf("got %+v, want healthy axis x after the normal is learned", steppers)
+	}
+
+	// recent samples drift by 10 % and the axis crashes twice
+	for i := 0; i < qualityHistorySize; i++ {
+		recordStepper("mac", "tmc_sg_x v=220i", now)
+	}
+	recordStepper("mac", "crash,axis=0 sens=2i,period=290i,speed=100.000", now.Add(-2*time.Hour))
+	recordStepper("mac", "crash,axis=0 sens=2i,period=290i,speed=100.000", now)
+
+	steppers = getStepperAxis(stepperByMac["mac"], now)
+	stepper := steppers[0]
+
+	if *stepper.baseline != 200 || math.Abs(*stepper.drift-0.1) > 1e-9 {
+		t.Errorf("got baseline %v and drift %v, want 200 and 0.1", *stepper.baseline, *stepper.drift)
+	}
+
+	if stepper.crashes != 2 || stepper.lastCrashes != 1 {
+		t.Errorf("got %v crashes and %d in the last hour, want 2 and 1", stepper.crashes, stepper.lastCrashes)
+	}
+
+	if math.Abs(*stepper.healthScore-0.7) > 1e-9 {
+		t.Errorf("got health score %v, want 0.7", *stepper.healthScore)
+	}
+}
+
+func TestStepperBaselineSaved(t *testing.T) {
+	loadCapture(t)
+	t.Cleanup(func() { stepperFile = defaultStepperFile })
+
+	if err := LoadSteppers(filepath.Join(t.TempDir(), "steppers.json")); err != nil {
+		t.Fatal(err)
+	}
+	now := learnStepper("mac", "200i", time.Now())
+	if err := SaveSteppers(); err != nil {
+		t.Fatal(err)
+	}
+
+	// normal is loaded after restart, recent samples are new
+	loadCapture(t)
+	if err := LoadSteppers(""); err != nil {
+		t.Fatal(err)
+	}
+	recordStepper("mac", "tmc_sg_x v=300i", now)
+
+	steppers := getStepperAxis(stepperByMac["mac"], now)
+	if len(steppers) != 1 || steppers[0].baseline == nil || *steppers[0].baseline != 200 || *steppers[0].drift != 0.5 {
+		t.Fatalf("got %+v, want stored normal 200 and drift 0.5", steppers)
+	}
+}
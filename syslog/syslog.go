@@ -1,13 +1,16 @@
 package syslog
 
 import (
+	"net"
 	"regexp"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/pstrobl96/prusa_exporter/prusalink"
 	"github.com/rs/zerolog/log"
 	"gopkg.in/mcuadros/go-syslog.v2"
+	"gopkg.in/mcuadros/go-syslog.v2/format"
 )
 
 type patterns struct {
@@ -76,94 +79,146 @@ func startSyslogServer(listenUDP string) (syslog.LogPartsChannel, *syslog.Server
 	return channel, server
 }
 
-// HandleMetrics is function that listens for syslog messages and parses them into map
-func HandleMetrics(listenUDP string) {
+// HandleMetrics is function that listens for syslog messages and parses them into map, meshes is number of bed meshes stored per printer
+func HandleMetrics(listenUDP string, meshes int) {
+	if meshes > 0 {
+		meshHistory = meshes
+	}
+
 	channel, server := startSyslogServer(listenUDP)
 	log.Debug().Msg("Syslog server started at: " + listenUDP)
 	go func(channel syslog.LogPartsChannel) {
 		for logParts := range channel {
-			mac := logParts["hostname"].(string)
-			if mac == "" { // Skip empty mac addresses
-				continue
-			} else {
-				mutex.Lock()
-				loadedPart := syslogMetrics[mac]
+			handleMetricsPacket(logParts)
+		}
+	}(channel)
 
-				if loadedPart == nil {
-					loadedPart = make(map[string]map[string]string) // if found but empty, create a new map, at start it will be empty everytime
-				}
+	server.Wait()
+}
 
-				if loadedPart["ip"] == nil {
-					loadedPart["ip"] = make(map[string]string)
-				}
+// handleMetricsPacket parses one syslog packet with metrics and stores its values in syslogMetrics
+func handleMetricsPacket(logParts format.LogParts) {
+	mac := logParts["hostname"].(string)
+	if mac == "" { // Skip empty mac addresses
+		return
+	}
 
-				if loadedPart["timestamp"] == nil {
-					loadedPart["timestamp"] = make(map[string]string)
-				}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	loadedPart := syslogMetrics[mac]
+
+	if loadedPart == nil {
+		loadedPart = make(map[string]map[string]string) // if found but empty, create a new map, at start it will be empty everytime
+	}
 
-				loadedPart["ip"]["value"] = logParts["client"].(string)
-				loadedPart["timestamp"]["value"] = time.Now().Format(time.RFC3339Nano)
+	if loadedPart["ip"] == nil {
+		loadedPart["ip"] = make(map[string]string)
+	}
 
-				log.Trace().Msg("Received message from: " + mac)
+	if loadedPart["timestamp"] == nil {
+		loadedPart["timestamp"] = make(map[string]string)
+	}
 
-				message := logParts["message"].(string)
+	loadedPart["ip"]["value"] = logParts["client"].(string)
+	loadedPart["timestamp"]["value"] = time.Now().Format(time.RFC3339Nano)
 
-				var splittedMessage []string
+	log.Trace().Msg("Received message from: " + mac)
 
-				if strings.Contains(message, "\n") {
-					splittedMessage = strings.Split(logParts["message"].(string), "\n")
-				} else {
-					splittedMessage = []string{logParts["message"].(string)}
-				}
+	message := logParts["message"].(string)
 
-				for _, message := range splittedMessage {
-					for name, pattern := range regexpPatterns {
+	var splittedMessage []string
 
-						reg, err := regexp.Compile(pattern.pattern)
-						if err != nil {
-							log.Error().Msg("Error compiling regexp: " + err.Error())
-							continue
-						}
+	if strings.Contains(message, "\n") {
+		splittedMessage = strings.Split(message, "\n")
+	} else {
+		splittedMessage = []string{message}
+	}
 
-						log.Trace().Msg("Matching pattern: " + name + " for message: " + message)
+	for _, message := range splittedMessage {
+		if match := mmuCommValue.FindStringSubmatch(message); match != nil {
+			recordMMUComm(mac, match[1]) // counters need every message, not only the last one stored in syslogMetrics
+		}
+		recordQuality(mac, message)
+		recordMesh(mac, message, time.Now())
+		recordStepper(mac, message, time.Now())
 
-						matches := reg.FindAllStringSubmatch(message, -1)
-						if matches == nil {
-							continue // No matches for this pattern
-						}
-						var metricName string
-
-						for _, match := range matches {
-							// Extract values based on named groups
-
-							suffix := ""
-
-							for i, field := range pattern.fields {
-								if field == "n" {
-									suffix = "_" + match[i+1]
-								}
-							}
-
-							for i, field := range pattern.fields {
-								if field == "name" {
-									metricName = match[i+1] + suffix
-								} else if match[i+1] != "" && field != "timestamp" { // todo - check if timestamp is needed
-									if loadedPart[metricName] == nil {
-										loadedPart[metricName] = make(map[string]string)
-									}
-									loadedPart[metricName][field] = match[i+1]
-								}
-							}
+		for name, pattern := range regexpPatterns {
+
+			reg, err := regexp.Compile(pattern.pattern)
+			if err != nil {
+				log.Error().Msg("Error compiling regexp: " + err.Error())
+				continue
+			}
+
+			log.Trace().Msg("Matching pattern: " + name + " for message: " + message)
+
+			matches := reg.FindAllStringSubmatch(message, -1)
+			if matches == nil {
+				continue // No matches for this pattern
+			}
+			var metricName string
+
+			for _, match := range matches {
+				// Extract values based on named groups
+
+				suffix := ""
+
+				for i, field := range pattern.fields {
+					if field == "n" {
+						suffix = "_" + match[i+1]
+					}
+				}
+
+				for i, field := range pattern.fields {
+					if field == "name" {
+						metricName = match[i+1] + suffix
+					} else if match[i+1] != "" && field != "timestamp" { // todo - check if timestamp is needed
+						if loadedPart[metricName] == nil {
+							loadedPart[metricName] = make(map[string]string)
 						}
+						loadedPart[metricName][field] = match[i+1]
 					}
 				}
+			}
+		}
+	}
 
-				syslogMetrics[mac] = loadedPart
+	syslogMetrics[mac] = loadedPart
 
-				mutex.Unlock()
-			}
+	prusalink.SetSyslogHints(getHost(loadedPart["ip"]["value"]), getHints(loadedPart))
+
+	if power, ok := getPrinterPower(getRailPower(loadedPart)); ok {
+		prusalink.SetSyslogPower(getHost(loadedPart["ip"]["value"]), power)
+	}
+}
+
+// getHost returns host of the address without port - e.g. 192.168.20.12 for 192.168.20.12:514 and fd00::12 for [fd00::12]:514
+func getHost(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
+
+// getHints returns hardware hints used for detection of the printer model
+func getHints(metrics map[string]map[string]string) prusalink.SyslogHints {
+	hints := prusalink.SyslogHints{}
+
+	if metrics["buddy_bom"] != nil {
+		hints.BuddyBom = metrics["buddy_bom"]["value"]
+	}
+	if metrics["buddy_revision"] != nil {
+		hints.BuddyRevision = metrics["buddy_revision"]["value"]
+	}
+
+	for name := range metrics {
+		if strings.HasPrefix(name, "loadcell") {
+			hints.Loadcell = true
+		} else if strings.HasPrefix(name, "dwarf") {
+			hints.Dwarfs = true
 		}
-	}(channel)
+	}
 
-	server.Wait()
+	return hints
 }